@@ -1,6 +1,9 @@
 package folderWatcher
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 //
 type FileEvent struct {
@@ -8,7 +11,25 @@ type FileEvent struct {
 	FilePath string
 	PreviousPath string
 	Description string
-
+	// the Path of the watch the file belongs to, or the name of the watch for a file system added with AddFS. When
+	// watches overlap, the file belongs to the one with the deepest folder.
+	Root string
+	// FilePath and PreviousPath relative to the watched folder. PreviousRelativePath starts with .. when the file was
+	// moved from outside the folder.
+	RelativePath string
+	PreviousRelativePath string
+	// when the change was detected by a scan
+	DetectedAt time.Time
+	// the modification time of the file when it was scanned. For a Remove event, this is the last one seen.
+	ModTime time.Time
+	// the number of the scan which detected the change, starting at 1
+	Cycle uint64
+	// increases by one for each event sent by the watcher, starting at 1. Gaps mean events were dropped.
+	Sequence uint64
+	// the file before the change, which is nil for an Add event
+	Old *FileMetadata
+	// the file after the change, which is nil for a Remove event
+	New *FileMetadata
 }
 
 // FileChange enumeration
@@ -18,10 +39,14 @@ const (
 	Remove FileChange = 1
 	Write FileChange =2
 	Move FileChange =3
+	// events were dropped because the event queue was full
+	Overflow FileChange = 4
+	// summarizes the changes to a folder during a storm of changes, when the folder should be rescanned
+	Storm FileChange = 5
 )
 
 func (fc FileChange) String() string {
-	fileChangeStrings:= [...]string{"Add", "Remove", "Write", "Move"}
+	fileChangeStrings:= [...]string{"Add", "Remove", "Write", "Move", "Overflow", "Storm"}
 	return fileChangeStrings[fc]
 }
 
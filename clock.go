@@ -0,0 +1,17 @@
+package folderWatcher
+
+import "time"
+
+// Clock is the source of time for a Watcher. It can be replaced to control the polling cycle in tests.
+type Clock interface {
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock for the real time. This is the default for a new Watcher.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
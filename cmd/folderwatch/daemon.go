@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/mikerapa/FolderWatcher/daemon"
+)
+
+// options for the daemon command
+type daemonOptions struct {
+	options
+	socketPath string
+}
+
+// Parse the arguments for serving a watcher to other processes
+func parseDaemonOptions(args []string, output io.Writer) (opts daemonOptions, err error) {
+	flags := flag.NewFlagSet("folderwatch daemon", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintln(output, "Usage: folderwatch daemon --socket path [flags] path...")
+		fmt.Fprintln(output, "Clients choose their own filters, so there are no include or exclude flags.")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.socketPath, "socket", "", "the Unix domain socket to serve subscriptions on")
+	flags.BoolVar(&opts.recursive, "recursive", false, "watch subfolders")
+	flags.BoolVar(&opts.hidden, "hidden", false, "watch hidden files")
+	flags.DurationVar(&opts.interval, "interval", 0, "time between scans, calculated from the number of files when 0")
+
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	if opts.socketPath == "" {
+		err = errors.New("a socket path is required")
+		return
+	}
+	opts.paths = flags.Args()
+	if len(opts.paths) == 0 {
+		err = errors.New("at least one path to watch is required")
+	}
+	return
+}
+
+// Serve subscriptions to the watcher until the context is cancelled
+func serveDaemon(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) (err error) {
+	opts, err := parseDaemonOptions(args, stderr)
+	if err != nil {
+		return
+	}
+	watcher, err := opts.newWatcher()
+	if err != nil {
+		return
+	}
+	listener, err := daemon.Listen(opts.socketPath)
+	if err != nil {
+		return
+	}
+
+	server := daemon.NewServer(&watcher)
+	watcher.Start()
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+		go func() { <-watcher.Stopped }()
+		watcher.Stop()
+	}()
+
+	fmt.Fprintf(stdout, "serving %d folders on %s\n", len(opts.paths), opts.socketPath)
+	if err = server.Serve(listener); errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return
+}
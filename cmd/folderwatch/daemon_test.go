@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseDaemonOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "socket and path", args: []string{"--socket", "/tmp/folderwatch.sock", "--recursive", "/data"}, wantErr: false},
+		{name: "no socket", args: []string{"/data"}, wantErr: true},
+		{name: "no path", args: []string{"--socket", "/tmp/folderwatch.sock"}, wantErr: true},
+		{name: "include is not supported", args: []string{"--socket", "/tmp/folderwatch.sock", "--include", "*.go", "/data"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseDaemonOptions(tt.args, ioutil.Discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDaemonOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (opts.socketPath != tt.args[1] || !opts.recursive || len(opts.paths) != 1) {
+				t.Errorf("parseDaemonOptions() = %+v", opts)
+			}
+		})
+	}
+}
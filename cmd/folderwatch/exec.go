@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// options for the exec command
+type execOptions struct {
+	options
+	command  []string
+	debounce time.Duration
+	restart  bool
+	initial  bool
+}
+
+// Parse the arguments of the exec command. The command to run follows "--".
+func parseExecOptions(args []string, output io.Writer) (opts execOptions, err error) {
+	flags := flag.NewFlagSet("folderwatch exec", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintln(output, "Usage: folderwatch exec [flags] [path...] -- command [argument...]")
+		fmt.Fprintln(output, "Arguments can contain {path}, {previous} and {kind} for the latest change, and an argument of {paths}")
+		fmt.Fprintln(output, "is replaced by every changed path. The changes are also in FOLDERWATCH_* environment variables.")
+		flags.PrintDefaults()
+	}
+	opts.addFlags(flags)
+	flags.DurationVar(&opts.debounce, "debounce", 200*time.Millisecond, "wait for changes to stop for this long before running the command")
+	mode := flags.String("mode", "queue", "when changes arrive while the command is running: queue runs it again afterwards, restart kills it and runs it again")
+	flags.BoolVar(&opts.initial, "initial", false, "run the command once when starting")
+
+	separator := len(args)
+	for i, arg := range args {
+		if arg == "--" {
+			separator = i
+			break
+		}
+	}
+	if err = flags.Parse(args[:separator]); err != nil {
+		return
+	}
+	if separator < len(args) {
+		opts.command = args[separator+1:]
+	}
+	if len(opts.command) == 0 {
+		err = errors.New("a command to run is required after --")
+		return
+	}
+
+	switch *mode {
+	case "queue":
+	case "restart":
+		opts.restart = true
+	default:
+		err = errors.New(fmt.Sprintf("%s is not a valid mode", *mode))
+		return
+	}
+
+	opts.paths = flags.Args()
+	if len(opts.paths) == 0 {
+		opts.paths = []string{"."}
+	}
+	return
+}
+
+// Build the arguments for the command from the templates, using the changes in the batch
+func expandArguments(templates []string, batch []folderWatcher.FileEvent) (arguments []string) {
+	var latest folderWatcher.FileEvent
+	if len(batch) > 0 {
+		latest = batch[len(batch)-1]
+	}
+	replacer := strings.NewReplacer("{path}", latest.FilePath, "{previous}", latest.PreviousPath, "{kind}", kindString(latest, batch))
+
+	for _, template := range templates {
+		if template == "{paths}" {
+			arguments = append(arguments, changedPaths(batch)...)
+			continue
+		}
+		arguments = append(arguments, replacer.Replace(template))
+	}
+	return
+}
+
+// The kind of the change, or an empty string when there are no changes, as for the initial run
+func kindString(event folderWatcher.FileEvent, batch []folderWatcher.FileEvent) string {
+	if len(batch) == 0 {
+		return ""
+	}
+	return event.FileChange.String()
+}
+
+// The distinct paths in the batch, sorted
+func changedPaths(batch []folderWatcher.FileEvent) (paths []string) {
+	pathSet := make(map[string]bool)
+	for _, event := range batch {
+		pathSet[event.FilePath] = true
+	}
+	for p := range pathSet {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return
+}
+
+// Environment variables describing the changes in the batch
+func changeEnvironment(batch []folderWatcher.FileEvent) []string {
+	var latest folderWatcher.FileEvent
+	if len(batch) > 0 {
+		latest = batch[len(batch)-1]
+	}
+	return []string{
+		"FOLDERWATCH_PATH=" + latest.FilePath,
+		"FOLDERWATCH_PREVIOUS_PATH=" + latest.PreviousPath,
+		"FOLDERWATCH_KIND=" + kindString(latest, batch),
+		"FOLDERWATCH_PATHS=" + strings.Join(changedPaths(batch), string(filepath.ListSeparator)),
+		"FOLDERWATCH_EVENT_COUNT=" + strconv.Itoa(len(batch)),
+	}
+}
+
+// time a process has to exit after it is asked to stop before it is killed
+const killGracePeriod = 2 * time.Second
+
+// process is a run of the command
+type process struct {
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// Start the command for the batch of changes
+func startProcess(templates []string, batch []folderWatcher.FileEvent, stdout io.Writer, stderr io.Writer) (p *process, err error) {
+	arguments := expandArguments(templates, batch)
+	if len(arguments) == 0 {
+		err = errors.New(fmt.Sprintf("%s expands to no command", strings.Join(templates, " ")))
+		return
+	}
+	cmd := exec.Command(arguments[0], arguments[1:]...)
+	cmd.Env = append(os.Environ(), changeEnvironment(batch)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	if err = cmd.Start(); err != nil {
+		return
+	}
+
+	p = &process{cmd: cmd, done: make(chan error, 1)}
+	go func() {
+		p.done <- cmd.Wait()
+	}()
+	return
+}
+
+// Stop the process and everything it started, and wait for it to exit. The processes of the group which are still
+// running after the grace period are killed, even if the command itself has exited by then.
+func (p *process) stop() {
+	terminateProcessGroup(p.cmd)
+	time.AfterFunc(killGracePeriod, func() {
+		if processGroupRunning(p.cmd) {
+			killProcessGroup(p.cmd)
+		}
+	})
+	<-p.done
+}
+
+// Watch the folders and run the command when files change, until the context is cancelled
+func execute(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) (err error) {
+	opts, err := parseExecOptions(args, stderr)
+	if err != nil {
+		return
+	}
+	watcher, err := opts.newWatcher()
+	if err != nil {
+		return
+	}
+	sub, err := watcher.Subscribe(folderWatcher.Filter{}, 0, folderWatcher.Block)
+	if err != nil {
+		return
+	}
+
+	var running *process
+	var runningDone <-chan error
+	var batch, queued []folderWatcher.FileEvent
+	var debounceTimer <-chan time.Time
+
+	run := func(changes []folderWatcher.FileEvent) {
+		p, startErr := startProcess(opts.command, changes, stdout, stderr)
+		if startErr != nil {
+			fmt.Fprintln(stderr, startErr.Error())
+			return
+		}
+		running, runningDone = p, p.done
+	}
+
+	if opts.initial {
+		run(nil)
+	}
+	watcher.Start()
+	go func() {
+		<-ctx.Done()
+		watcher.Stop()
+	}()
+
+	for {
+		select {
+		case <-watcher.Stopped:
+			sub.Unsubscribe()
+			if running != nil {
+				running.stop()
+			}
+			return
+		case event := <-sub.Events():
+			if !opts.match(event) {
+				continue
+			}
+			// wait for the changes to stop before running the command
+			batch = append(batch, event)
+			debounceTimer = time.After(opts.debounce)
+		case <-debounceTimer:
+			debounceTimer = nil
+			switch {
+			case running == nil:
+				run(batch)
+			case opts.restart:
+				running.stop()
+				run(append(queued, batch...))
+				queued = nil
+			default:
+				// run again once the current run finishes
+				queued = append(queued, batch...)
+			}
+			batch = nil
+		case exitErr := <-runningDone:
+			if exitErr != nil {
+				fmt.Fprintf(stderr, "%s: %s\n", opts.command[0], exitErr.Error())
+			}
+			running, runningDone = nil, nil
+			if len(queued) > 0 {
+				run(queued)
+				queued = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+func TestParseExecOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantErr     bool
+		wantPaths   int
+		wantRestart bool
+	}{
+		{name: "no command", args: []string{"--recursive", "."}, wantErr: true},
+		{name: "empty command", args: []string{".", "--"}, wantErr: true},
+		{name: "bad mode", args: []string{"--mode", "later", "--", "make"}, wantErr: true},
+		{name: "default path", args: []string{"--", "go", "test", "./..."}, wantPaths: 1},
+		{name: "restart", args: []string{"--mode", "restart", "--debounce", "1s", "src", "cmd", "--", "make"}, wantPaths: 2, wantRestart: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseExecOptions(tt.args, ioutil.Discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExecOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(opts.paths) != tt.wantPaths || opts.restart != tt.wantRestart {
+				t.Errorf("parseExecOptions() paths = %v, restart = %v, want %d paths and restart %v", opts.paths, opts.restart, tt.wantPaths, tt.wantRestart)
+			}
+		})
+	}
+}
+
+func TestExpandArguments(t *testing.T) {
+	batch := []folderWatcher.FileEvent{
+		{FileChange: folderWatcher.Write, FilePath: "/src/b.go"},
+		{FileChange: folderWatcher.Add, FilePath: "/src/a.go"},
+		{FileChange: folderWatcher.Move, FilePath: "/src/c.go", PreviousPath: "/src/b.go"},
+	}
+	got := expandArguments([]string{"lint", "--kind={kind}", "{previous}", "{path}", "{paths}"}, batch)
+	want := []string{"lint", "--kind=Move", "/src/b.go", "/src/c.go", "/src/a.go", "/src/b.go", "/src/c.go"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("expandArguments() = %v, want %v", got, want)
+	}
+
+	environment := strings.Join(changeEnvironment(batch), "\n")
+	for _, variable := range []string{"FOLDERWATCH_KIND=Move", "FOLDERWATCH_PATH=/src/c.go", "FOLDERWATCH_EVENT_COUNT=3"} {
+		if !strings.Contains(environment, variable) {
+			t.Errorf("changeEnvironment() should contain %s, got %s", variable, environment)
+		}
+	}
+
+	// without changes, as for the initial run, the templates are empty
+	if got = expandArguments([]string{"echo", "{kind}{path}"}, nil); got[1] != "" {
+		t.Errorf("expandArguments() without changes should replace the templates with nothing, got %v", got)
+	}
+}
+
+// A command whose templates expand to nothing is not run
+func TestStartProcess_NoCommand(t *testing.T) {
+	if _, err := startProcess([]string{"{paths}"}, nil, ioutil.Discard, ioutil.Discard); err == nil {
+		t.Error("startProcess() should return an error when the command expands to nothing")
+	}
+}
+
+// Stopping a command also kills the processes it started which ignore SIGTERM, after the command itself has exited
+func TestProcess_StopGroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("uses sh and reads the process state from /proc")
+	}
+	stdout := &bytes.Buffer{}
+	p, err := startProcess([]string{"sh", "-c", "(trap '' TERM; exec sleep 30) >/dev/null 2>&1 & echo $!; sleep 30"}, nil, stdout, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// give the shell time to start the process which ignores SIGTERM
+	time.Sleep(250 * time.Millisecond)
+	childPID := strings.TrimSpace(stdout.String())
+	p.stop()
+
+	// the child is killed at the end of the grace period. Once killed it is gone, or a zombie until it is reaped.
+	time.Sleep(killGracePeriod + 250*time.Millisecond)
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", childPID, "stat"))
+	if err == nil {
+		if fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:])); fields[0] != "Z" {
+			t.Errorf("the process %s which ignores SIGTERM should have been killed, its state is %s", childPID, fields[0])
+		}
+	}
+}
+
+// A change while the command is running restarts it, and cancelling stops it
+func TestExecute_Restart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	folderPath := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout := &bytes.Buffer{}
+	done := make(chan error)
+	go func() {
+		done <- run(ctx, []string{"exec", "--interval", "100ms", "--debounce", "50ms", "--mode", "restart", folderPath,
+			"--", "sh", "-c", "echo started {kind} $FOLDERWATCH_EVENT_COUNT; sleep 30"}, stdout, ioutil.Discard)
+	}()
+
+	time.Sleep(250 * time.Millisecond)
+	_ = ioutil.WriteFile(filepath.Join(folderPath, "a.txt"), []byte("a"), 0644)
+	time.Sleep(500 * time.Millisecond)
+	_ = ioutil.WriteFile(filepath.Join(folderPath, "b.txt"), []byte("b"), 0644)
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the running command was not stopped when the context was cancelled")
+	}
+	if startCount := strings.Count(stdout.String(), "started Add 1"); startCount != 2 {
+		t.Errorf("the command should have been started twice, got %d: %s", startCount, stdout.String())
+	}
+}
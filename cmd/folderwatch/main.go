@@ -0,0 +1,208 @@
+// Command folderwatch reports changes to files in one or more folders.
+//
+// Usage:
+//
+//	folderwatch [flags] path...
+//	folderwatch exec [flags] [path...] -- command [argument...]
+//	folderwatch daemon --socket path [flags] path...
+//
+// The first form writes events to stdout. The second runs a command whenever files change. The third serves
+// subscriptions to other processes over a Unix domain socket. All of them run until the process receives SIGINT or
+// SIGTERM.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// patternList collects the values of a flag which can be repeated
+type patternList []string
+
+func (pl *patternList) String() string {
+	return strings.Join(*pl, ",")
+}
+
+func (pl *patternList) Set(pattern string) (err error) {
+	if _, err = filepath.Match(pattern, ""); err != nil {
+		return errors.New(fmt.Sprintf("%s is not a valid pattern", pattern))
+	}
+	*pl = append(*pl, pattern)
+	return
+}
+
+// options common to the folderwatch commands
+type options struct {
+	paths     []string
+	recursive bool
+	hidden    bool
+	includes  patternList
+	excludes  patternList
+	interval  time.Duration
+	storm     int
+	settle    time.Duration
+	format    string
+}
+
+// Add the flags for the watch options to the flag set
+func (o *options) addFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&o.recursive, "recursive", false, "watch subfolders")
+	flags.BoolVar(&o.hidden, "hidden", false, "watch hidden files")
+	flags.Var(&o.includes, "include", "only report files matching this pattern (can be repeated)")
+	flags.Var(&o.excludes, "exclude", "do not report files matching this pattern (can be repeated)")
+	flags.DurationVar(&o.interval, "interval", 0, "time between scans, calculated from the number of files when 0")
+	flags.IntVar(&o.storm, "storm", 0, "report a scan with more changes than this as one Storm event per folder, disabled when 0")
+	flags.DurationVar(&o.settle, "settle", 0, "only report added and written files once they have not changed for this long")
+}
+
+// Check if an event passes the include and exclude patterns. Patterns without a separator match the file name.
+// Storm events are for a folder, which may contain matching files, so they always pass.
+func (o *options) match(event folderWatcher.FileEvent) bool {
+	if event.FileChange == folderWatcher.Storm {
+		return true
+	}
+	for _, pattern := range o.excludes {
+		if (folderWatcher.Filter{Glob: pattern}).Match(event) {
+			return false
+		}
+	}
+	if len(o.includes) == 0 {
+		return true
+	}
+	for _, pattern := range o.includes {
+		if (folderWatcher.Filter{Glob: pattern}).Match(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create a watcher for the paths in the options
+func (o *options) newWatcher() (watcher folderWatcher.Watcher, err error) {
+	watcher = folderWatcher.New()
+	watcher.FileChanged = nil
+	watcher.IntervalOverride = int(o.interval / time.Millisecond)
+	watcher.StormEventsPerScan = o.storm
+	watcher.SettleDuration = o.settle
+	for _, path := range o.paths {
+		if folderWatcher.IsValidPath(path) && !folderWatcher.IsValidDirPath(path) {
+			err = watcher.AddFile(path)
+		} else {
+			err = watcher.AddFolder(path, o.recursive, o.hidden)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Parse the arguments for watching and printing events
+func parseWatchOptions(args []string, output io.Writer) (opts options, err error) {
+	flags := flag.NewFlagSet("folderwatch", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintln(output, "Usage: folderwatch [flags] path...")
+		flags.PrintDefaults()
+	}
+	opts.addFlags(flags)
+	flags.StringVar(&opts.format, "format", "human", "output format: human, json or csv")
+
+	if err = flags.Parse(args); err != nil {
+		return
+	}
+	opts.paths = flags.Args()
+	if len(opts.paths) == 0 {
+		err = errors.New("at least one path to watch is required")
+		return
+	}
+	if _, found := eventWriters[opts.format]; !found {
+		err = errors.New(fmt.Sprintf("%s is not a valid format", opts.format))
+	}
+	return
+}
+
+// Watch the folders and write the events until the context is cancelled
+func watch(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) (err error) {
+	opts, err := parseWatchOptions(args, stderr)
+	if err != nil {
+		return
+	}
+	watcher, err := opts.newWatcher()
+	if err != nil {
+		return
+	}
+	sub, err := watcher.Subscribe(folderWatcher.Filter{}, 0, folderWatcher.Block)
+	if err != nil {
+		return
+	}
+	writer := eventWriters[opts.format](stdout)
+
+	watcher.Start()
+	go func() {
+		<-ctx.Done()
+		watcher.Stop()
+	}()
+
+	for {
+		select {
+		case <-watcher.Stopped:
+			// write whatever was delivered before the watcher stopped
+			sub.Unsubscribe()
+			for event := range sub.Events() {
+				if opts.match(event) {
+					err = writer.write(event)
+				}
+			}
+			if err == nil {
+				err = writer.flush()
+			}
+			return
+		case event := <-sub.Events():
+			if !opts.match(event) {
+				continue
+			}
+			if err = writer.write(event); err == nil {
+				err = writer.flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Run the command selected by the arguments
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "exec" {
+		return execute(ctx, args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "daemon" {
+		return serveDaemon(ctx, args[1:], stdout, stderr)
+	}
+	return watch(ctx, args, stdout, stderr)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		stop()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+func TestParseWatchOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "no paths", args: []string{"--recursive"}, wantErr: true},
+		{name: "bad format", args: []string{"--format", "xml", "."}, wantErr: true},
+		{name: "bad pattern", args: []string{"--include", "[a-", "."}, wantErr: true},
+		{name: "unknown flag", args: []string{"--fast", "."}, wantErr: true},
+		{name: "valid", args: []string{"--recursive", "--hidden", "--include", "*.go", "--exclude", "*_test.go", "--interval", "2s", "--format", "csv", ".", ".."}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseWatchOptions(tt.args, ioutil.Discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWatchOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (!opts.recursive || !opts.hidden || opts.interval != 2*time.Second || len(opts.paths) != 2 || opts.format != "csv") {
+				t.Errorf("parseWatchOptions() did not set the options: %+v", opts)
+			}
+		})
+	}
+}
+
+func TestOptions_Match(t *testing.T) {
+	opts := options{includes: patternList{"*.go"}, excludes: patternList{"*_test.go"}}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/src/main.go", true},
+		{"/src/main_test.go", false},
+		{"/src/README.md", false},
+	}
+	for _, tt := range tests {
+		if got := opts.match(folderWatcher.FileEvent{FileChange: folderWatcher.Add, FilePath: tt.path}); got != tt.want {
+			t.Errorf("match(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if !opts.match(folderWatcher.FileEvent{FileChange: folderWatcher.Storm, FilePath: "/src"}) {
+		t.Error("Storm events should always match")
+	}
+}
+
+func TestEventWriters(t *testing.T) {
+	event := folderWatcher.FileEvent{FileChange: folderWatcher.Move, FilePath: "/data/new.txt", PreviousPath: "/data/old.txt",
+		Description: "/data/old.txt move to /data/new.txt", DetectedAt: time.Date(2020, 12, 30, 10, 4, 5, 0, time.Local)}
+	tests := []struct {
+		format    string
+		wantLines int
+		wantText  string
+	}{
+		{format: "human", wantLines: 2, wantText: "10:04:05 Move     /data/old.txt move to /data/new.txt"},
+		{format: "json", wantLines: 2, wantText: `"kind":"Move","path":"/data/new.txt","previousPath":"/data/old.txt"`},
+		{format: "csv", wantLines: 3, wantText: "Move,/data/new.txt,/data/old.txt,"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			writer := eventWriters[tt.format](output)
+			_ = writer.write(event)
+			_ = writer.write(event)
+			if err := writer.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if lineCount := strings.Count(output.String(), "\n"); lineCount != tt.wantLines {
+				t.Errorf("want %d lines, got %d: %s", tt.wantLines, lineCount, output.String())
+			}
+			if !strings.Contains(output.String(), tt.wantText) {
+				t.Errorf("output should contain %s, got %s", tt.wantText, output.String())
+			}
+		})
+	}
+}
+
+// Run the command until the context is cancelled, as it would be by a signal
+func TestRun(t *testing.T) {
+	folderPath := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout := &bytes.Buffer{}
+	done := make(chan error)
+	go func() {
+		done <- run(ctx, []string{"--interval", "100ms", "--exclude", "*.tmp", "--format", "json", folderPath}, stdout, ioutil.Discard)
+	}()
+
+	time.Sleep(250 * time.Millisecond)
+	_ = ioutil.WriteFile(filepath.Join(folderPath, "new.txt"), []byte("new"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(folderPath, "ignored.tmp"), []byte("ignored"), 0644)
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run() did not return after the context was cancelled")
+	}
+	if !strings.Contains(stdout.String(), `"kind":"Add"`) || !strings.Contains(stdout.String(), "new.txt") {
+		t.Errorf("output should contain an Add event for new.txt, got %s", stdout.String())
+	}
+	if strings.Contains(stdout.String(), "ignored.tmp") {
+		t.Errorf("output should not contain the excluded file, got %s", stdout.String())
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// eventWriter writes events to the output in one of the formats
+type eventWriter interface {
+	write(event folderWatcher.FileEvent) error
+	flush() error
+}
+
+// the output formats, by name
+var eventWriters = map[string]func(output io.Writer) eventWriter{
+	"human": func(output io.Writer) eventWriter { return &humanWriter{output: output} },
+	"json":  func(output io.Writer) eventWriter { return &jsonWriter{writer: folderWatcher.NewEventWriter(output)} },
+	"csv":   func(output io.Writer) eventWriter { return &csvWriter{writer: csv.NewWriter(output)} },
+}
+
+// humanWriter writes one line of text for each event
+type humanWriter struct {
+	output io.Writer
+}
+
+func (hw *humanWriter) write(event folderWatcher.FileEvent) (err error) {
+	_, err = fmt.Fprintf(hw.output, "%s %-8s %s\n", event.DetectedAt.Format("15:04:05"), event.FileChange, event.Description)
+	return
+}
+
+func (hw *humanWriter) flush() error { return nil }
+
+// jsonWriter writes each event as a line of JSON, using the library's encoding so the output can be read back with
+// folderWatcher.NewEventReader
+type jsonWriter struct {
+	writer *folderWatcher.EventWriter
+}
+
+func (jw *jsonWriter) write(event folderWatcher.FileEvent) error {
+	return jw.writer.Write(event)
+}
+
+func (jw *jsonWriter) flush() error { return nil }
+
+// csvWriter writes each event as a CSV record, after a header record
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (cw *csvWriter) write(event folderWatcher.FileEvent) (err error) {
+	if !cw.headerWritten {
+		if err = cw.writer.Write([]string{"time", "kind", "path", "previous_path", "description"}); err != nil {
+			return
+		}
+		cw.headerWritten = true
+	}
+	return cw.writer.Write([]string{event.DetectedAt.Format(time.RFC3339Nano), event.FileChange.String(), event.FilePath,
+		event.PreviousPath, event.Description})
+}
+
+func (cw *csvWriter) flush() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Start the command in a new process group
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// Windows does not provide a way to ask a process group to exit, so the process is killed
+func terminateProcessGroup(cmd *exec.Cmd) {
+	killProcessGroup(cmd)
+}
+
+// Kill the process. Windows does not provide a way to signal a process group, so processes started by the command
+// may keep running.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// Windows cannot list the processes of a group, and terminateProcessGroup has already killed the process
+func processGroupRunning(cmd *exec.Cmd) bool {
+	return false
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// Client subscribes to the watcher of a Server. It is a folderWatcher.EventSource, so it can be used in place of a
+// local Watcher by code which only consumes events.
+type Client struct {
+	socketPath string
+}
+
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+// Subscribe opens a connection to the server for the subscription. The server applies the filter and the policy to
+// its own buffer of bufferSize events, as a local Watcher would, except that Block disconnects the client. The
+// subscription ends when the server is closed or disconnects the client.
+func (c *Client) Subscribe(filter folderWatcher.Filter, bufferSize int, policy folderWatcher.OverflowPolicy) (sub folderWatcher.Subscription, err error) {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return
+	}
+
+	params, err := json.Marshal(subscribeParams{PathPrefix: filter.PathPrefix, Glob: filter.Glob, Kinds: filter.Kinds,
+		BufferSize: bufferSize, Policy: policy})
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	req := rpcRequest{JSONRPC: jsonRPCVersion, ID: json.RawMessage("1"), Method: subscribeMethod, Params: params}
+	decoder := json.NewDecoder(conn)
+	var resp rpcResponse
+	if err = json.NewEncoder(conn).Encode(req); err == nil {
+		err = decoder.Decode(&resp)
+	}
+	if err == nil && resp.Error != nil {
+		err = errors.New(resp.Error.Message)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	if bufferSize < 1 {
+		bufferSize = folderWatcher.DefaultSubscriptionBufferSize
+	}
+	remoteSub := &remoteSubscription{conn: conn, events: make(chan folderWatcher.FileEvent, bufferSize), stop: make(chan struct{})}
+	remoteSub.readers.Add(1)
+	go remoteSub.read(decoder)
+	sub = remoteSub
+	return
+}
+
+// remoteSubscription receives the events of a subscription from the server
+type remoteSubscription struct {
+	conn     net.Conn
+	events   chan folderWatcher.FileEvent
+	stop     chan struct{}
+	stopOnce sync.Once
+	readers  sync.WaitGroup
+}
+
+func (rs *remoteSubscription) Events() <-chan folderWatcher.FileEvent {
+	return rs.events
+}
+
+// Unsubscribe closes the connection, which ends the subscription on the server
+func (rs *remoteSubscription) Unsubscribe() {
+	rs.stopOnce.Do(func() {
+		close(rs.stop)
+		_ = rs.conn.Close()
+	})
+	rs.readers.Wait()
+}
+
+// Pass the events from the connection to the events channel until the connection is closed. Notifications of
+// other methods are ignored.
+func (rs *remoteSubscription) read(decoder *json.Decoder) {
+	defer rs.readers.Done()
+	defer close(rs.events)
+
+	for {
+		var notification struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := decoder.Decode(&notification); err != nil {
+			return
+		}
+		if notification.Method != eventMethod {
+			continue
+		}
+		var event folderWatcher.FileEvent
+		if err := json.Unmarshal(notification.Params, &event); err != nil {
+			return
+		}
+		select {
+		case rs.events <- event:
+		case <-rs.stop:
+			return
+		}
+	}
+}
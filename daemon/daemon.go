@@ -0,0 +1,235 @@
+// Package daemon shares one folderWatcher.Watcher between processes on the same host. A Server owns the watcher and
+// serves subscriptions over a Unix domain socket, and a Client subscribes to it as if it were a local Watcher, so
+// the folders are only scanned once however many processes follow them.
+//
+// The protocol is JSON-RPC 2.0, with one message per line. A client connects and calls the subscribe method. If the
+// call succeeds, the server then sends the matching events as event notifications until either side closes the
+// connection.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+const jsonRPCVersion = "2.0"
+
+const (
+	subscribeMethod = "subscribe"
+	eventMethod     = "event"
+)
+
+// error codes defined by JSON-RPC 2.0
+const (
+	parseErrorCode     = -32700
+	invalidRequestCode = -32600
+	methodNotFoundCode = -32601
+	invalidParamsCode  = -32602
+)
+
+// rpcRequest is a JSON-RPC request, or a notification when it has no ID
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is the reply to a request, with either a result or an error
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// subscribeParams are the parameters of the subscribe method
+type subscribeParams struct {
+	PathPrefix string                       `json:"pathPrefix,omitempty"`
+	Glob       string                       `json:"glob,omitempty"`
+	Kinds      []folderWatcher.FileChange   `json:"kinds,omitempty"`
+	BufferSize int                          `json:"bufferSize,omitempty"`
+	Policy     folderWatcher.OverflowPolicy `json:"policy"`
+}
+
+// eventNotification sends an event to a subscribed client
+type eventNotification struct {
+	JSONRPC string                  `json:"jsonrpc"`
+	Method  string                  `json:"method"`
+	Params  folderWatcher.FileEvent `json:"params"`
+}
+
+// the result of a successful subscribe call
+var emptyResult = json.RawMessage("{}")
+
+// Server serves subscriptions to a watcher
+type Server struct {
+	watcher     *folderWatcher.Watcher
+	mutex       sync.Mutex
+	listeners   map[net.Listener]bool
+	connections map[net.Conn]bool
+	closed      bool
+	handlers    sync.WaitGroup
+}
+
+func NewServer(watcher *folderWatcher.Watcher) *Server {
+	return &Server{
+		watcher:     watcher,
+		listeners:   make(map[net.Listener]bool),
+		connections: make(map[net.Conn]bool),
+	}
+}
+
+// Listen listens on the Unix domain socket. A socket file left by a server which is no longer running is removed,
+// but any other file at the path is left alone and an error is returned. The socket file is removed when the listener
+// is closed.
+func Listen(socketPath string) (listener net.Listener, err error) {
+	if conn, dialErr := net.Dial("unix", socketPath); dialErr == nil {
+		_ = conn.Close()
+		err = errors.New(socketPath + " is already being served")
+		return
+	}
+	fileInfo, err := os.Lstat(socketPath)
+	if err == nil {
+		if fileInfo.Mode()&os.ModeSocket == 0 {
+			err = errors.New(socketPath + " already exists and is not a socket")
+			return
+		}
+		if err = os.Remove(socketPath); err != nil {
+			return
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	return net.Listen("unix", socketPath)
+}
+
+// Serve accepts connections until the listener fails or the server is closed. It always returns an error, which is
+// net.ErrClosed after Close.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		_ = listener.Close()
+		return net.ErrClosed
+	}
+	s.listeners[listener] = true
+	s.mutex.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mutex.Lock()
+			delete(s.listeners, listener)
+			if s.closed {
+				err = net.ErrClosed
+			}
+			s.mutex.Unlock()
+			return err
+		}
+
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			_ = conn.Close()
+			continue
+		}
+		s.connections[conn] = true
+		s.handlers.Add(1)
+		s.mutex.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// Close stops the listeners and ends every subscription
+func (s *Server) Close() (err error) {
+	s.mutex.Lock()
+	s.closed = true
+	for listener := range s.listeners {
+		if closeErr := listener.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	for conn := range s.connections {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.handlers.Wait()
+	return
+}
+
+// Serve one client's subscription
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+		s.mutex.Lock()
+		delete(s.connections, conn)
+		s.mutex.Unlock()
+		s.handlers.Done()
+	}()
+
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	replyError := func(id json.RawMessage, code int, message string) {
+		_ = encoder.Encode(rpcResponse{JSONRPC: jsonRPCVersion, ID: id, Error: &rpcError{Code: code, Message: message}})
+	}
+	var req rpcRequest
+	if err := decoder.Decode(&req); err != nil {
+		replyError(nil, parseErrorCode, err.Error())
+		return
+	}
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" || req.ID == nil {
+		// a subscription is a call, so a notification is not valid either
+		replyError(req.ID, invalidRequestCode, "the request must be a JSON-RPC 2.0 call with an id")
+		return
+	}
+	if req.Method != subscribeMethod {
+		replyError(req.ID, methodNotFoundCode, req.Method+" is not a supported method")
+		return
+	}
+	var params subscribeParams
+	if req.Params != nil {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			replyError(req.ID, invalidParamsCode, err.Error())
+			return
+		}
+	}
+	filter := folderWatcher.Filter{PathPrefix: params.PathPrefix, Glob: params.Glob, Kinds: params.Kinds}
+	// a client which stops reading must not hold up the watcher or the other clients, so Block disconnects it instead
+	policy := params.Policy
+	if policy == folderWatcher.Block {
+		policy = folderWatcher.Disconnect
+	}
+	sub, err := s.watcher.Subscribe(filter, params.BufferSize, policy)
+	if err != nil {
+		replyError(req.ID, invalidParamsCode, err.Error())
+		return
+	}
+	defer sub.Unsubscribe()
+	if err = encoder.Encode(rpcResponse{JSONRPC: jsonRPCVersion, ID: req.ID, Result: emptyResult}); err != nil {
+		return
+	}
+
+	// the client sends nothing more, so a read only returns when the connection is closed
+	go func() {
+		_, _ = conn.Read(make([]byte, 1))
+		sub.Unsubscribe()
+	}()
+
+	for event := range sub.Events() {
+		if err = encoder.Encode(eventNotification{JSONRPC: jsonRPCVersion, Method: eventMethod, Params: event}); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+// both can be used by code which only consumes events
+var _ folderWatcher.EventSource = (*folderWatcher.Watcher)(nil)
+var _ folderWatcher.EventSource = (*Client)(nil)
+
+// Start a watcher on a fake file system and serve it on a socket
+func newTestServer(t *testing.T) (watcher *folderWatcher.Watcher, fsys *folderwatchertest.FS, server *Server, socketPath string) {
+	fsys = folderwatchertest.NewFS()
+	fsys.Mkdir("/data/sub")
+	newWatcher := folderWatcher.New()
+	watcher = &newWatcher
+	watcher.FileSystem = fsys
+	watcher.Clock = folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	watcher.FileChanged = nil
+	_ = watcher.AddFolder("/data", true, false)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+
+	// socket paths have a short length limit, so the folder from t.TempDir may be too long
+	folder, err := os.MkdirTemp("", "daemon")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	socketPath = filepath.Join(folder, "watcher.sock")
+	listener, err := Listen(socketPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	server = NewServer(watcher)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() {
+		_ = server.Close()
+		watcher.Stop()
+		_ = os.RemoveAll(folder)
+	})
+	return
+}
+
+func receive(t *testing.T, sub folderWatcher.Subscription) (event folderWatcher.FileEvent) {
+	select {
+	case event = <-sub.Events():
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not receive an event")
+	}
+	return
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	watcher, fsys, _, socketPath := newTestServer(t)
+	client := NewClient(socketPath)
+
+	if _, err := client.Subscribe(folderWatcher.Filter{Glob: "["}, 0, folderWatcher.Block); err == nil {
+		t.Error("Subscribe() should return the server's error for an invalid filter")
+	}
+
+	subFolder, _ := client.Subscribe(folderWatcher.Filter{PathPrefix: "/data/sub"}, 0, folderWatcher.Block)
+	removes, _ := client.Subscribe(folderWatcher.Filter{Kinds: []folderWatcher.FileChange{folderWatcher.Remove}}, 0, folderWatcher.Block)
+	defer subFolder.Unsubscribe()
+	defer removes.Unsubscribe()
+
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	_, _ = watcher.ScanNow(context.Background())
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	_, _ = watcher.ScanNow(context.Background())
+	_ = fsys.Remove("/data/a.txt")
+	_, _ = watcher.ScanNow(context.Background())
+
+	if event := receive(t, subFolder); event.FileChange != folderWatcher.Add || event.FilePath != folderWatcher.AbsPath("/data/sub/b.txt") {
+		t.Errorf("want the Add event for /data/sub/b.txt, got %s event for %s", event.FileChange, event.FilePath)
+	}
+	if event := receive(t, removes); event.FileChange != folderWatcher.Remove || event.Sequence != 3 {
+		t.Errorf("want the Remove event with Sequence 3, got %s event with Sequence %d", event.FileChange, event.Sequence)
+	}
+
+	// an unsubscribed subscription's channel is closed
+	subFolder.Unsubscribe()
+	subFolder.Unsubscribe()
+	if _, ok := <-subFolder.Events(); ok {
+		t.Error("the events channel should be closed after Unsubscribe")
+	}
+}
+
+// Closing the server ends the subscriptions and the socket can be served again
+func TestServer_Close(t *testing.T) {
+	_, _, server, socketPath := newTestServer(t)
+	if _, err := Listen(socketPath); err == nil {
+		t.Error("Listen() should return an error while the socket is being served")
+	}
+
+	sub, err := NewClient(socketPath).Subscribe(folderWatcher.Filter{}, 0, folderWatcher.Block)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_ = server.Close()
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Error("should not receive an event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the events channel should be closed when the server is closed")
+	}
+
+	listener, err := Listen(socketPath)
+	if err != nil {
+		t.Fatalf("Listen() should replace the socket left by a closed server, got %v", err)
+	}
+	_ = listener.Close()
+}
+
+// A file which is not a socket is never replaced
+func TestListen_NotSocket(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "important.txt")
+	if err := os.WriteFile(filePath, []byte("data"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	if listener, err := Listen(filePath); err == nil {
+		_ = listener.Close()
+		t.Error("Listen() should return an error for a path which is not a socket")
+	}
+	if data, err := os.ReadFile(filePath); err != nil || string(data) != "data" {
+		t.Errorf("Listen() should leave the file alone, got %q and %v", data, err)
+	}
+}
+
+// Call a method on a new connection and return the response along with the decoder for what follows it
+func call(t *testing.T, socketPath string, req string) (resp rpcResponse, decoder *json.Decoder) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if _, err = fmt.Fprintln(conn, req); err != nil {
+		t.Fatal(err.Error())
+	}
+	decoder = json.NewDecoder(conn)
+	if err = decoder.Decode(&resp); err != nil {
+		t.Fatal(err.Error())
+	}
+	return
+}
+
+// The protocol is JSON-RPC 2.0, so clients in other languages can use a JSON-RPC library
+func TestServer_JSONRPC(t *testing.T) {
+	watcher, fsys, _, socketPath := newTestServer(t)
+
+	errorTests := []struct {
+		name string
+		req  string
+		code int
+	}{
+		{"invalid JSON", `{"jsonrpc" "2.0"}`, parseErrorCode},
+		{"no version", `{"id":1,"method":"subscribe"}`, invalidRequestCode},
+		{"notification", `{"jsonrpc":"2.0","method":"subscribe"}`, invalidRequestCode},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"unsubscribe"}`, methodNotFoundCode},
+		{"invalid params", `{"jsonrpc":"2.0","id":1,"method":"subscribe","params":{"glob":"["}}`, invalidParamsCode},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := call(t, socketPath, tt.req)
+			if resp.JSONRPC != jsonRPCVersion || resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("the response should have error code %d, got %+v", tt.code, resp)
+			}
+		})
+	}
+
+	resp, decoder := call(t, socketPath, `{"jsonrpc":"2.0","id":"a","method":"subscribe","params":{"kinds":["Add"]}}`)
+	if resp.JSONRPC != jsonRPCVersion || string(resp.ID) != `"a"` || resp.Error != nil || string(resp.Result) != "{}" {
+		t.Fatalf("the subscription should be accepted with the same id, got %+v", resp)
+	}
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	_, _ = watcher.ScanNow(context.Background())
+	var notification struct {
+		JSONRPC string                  `json:"jsonrpc"`
+		ID      json.RawMessage         `json:"id"`
+		Method  string                  `json:"method"`
+		Params  folderWatcher.FileEvent `json:"params"`
+	}
+	if err := decoder.Decode(&notification); err != nil {
+		t.Fatal(err.Error())
+	}
+	if notification.JSONRPC != jsonRPCVersion || notification.ID != nil || notification.Method != eventMethod ||
+		notification.Params.FilePath != "/data/a.txt" {
+		t.Errorf("the event should be sent as a notification, got %+v", notification)
+	}
+}
+
+// A client which subscribes and never reads is disconnected rather than holding up the other clients
+func TestServer_ClientNotReading(t *testing.T) {
+	watcher, fsys, _, socketPath := newTestServer(t)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer conn.Close()
+	req := `{"jsonrpc":"2.0","id":1,"method":"subscribe","params":{"bufferSize":1,"policy":1}}`
+	var resp rpcResponse
+	if _, err = fmt.Fprintln(conn, req); err == nil {
+		err = json.NewDecoder(conn).Decode(&resp)
+	}
+	if err != nil || resp.Error != nil {
+		t.Fatalf("the subscription should be accepted, got %v %v", err, resp.Error)
+	}
+
+	// enough events to fill the socket buffers of the client which is not reading
+	const scans, filesPerScan = 20, 200
+	sub, err := NewClient(socketPath).Subscribe(folderWatcher.Filter{}, scans*filesPerScan, folderWatcher.Block)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer sub.Unsubscribe()
+	go func() {
+		for scan := 0; scan < scans; scan++ {
+			for file := 0; file < filesPerScan; file++ {
+				fsys.WriteFile(fmt.Sprintf("/data/file%d-%d.txt", scan, file), []byte("a"))
+			}
+			_, _ = watcher.ScanNow(context.Background())
+		}
+	}()
+
+	for received := 0; received < scans*filesPerScan; received++ {
+		if event := receive(t, sub); event.FileChange != folderWatcher.Add {
+			t.Fatalf("want Add events, got %s", event.FileChange)
+		}
+	}
+}
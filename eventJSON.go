@@ -0,0 +1,153 @@
+package folderWatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Version of the JSON encoding of FileEvent. Fields may be added without changing the version, but existing fields
+// keep their names and meaning.
+const EventJSONVersion = 1
+
+// MarshalText encodes the kind of change as its name, for example "Add"
+func (fc FileChange) MarshalText() ([]byte, error) {
+	if fc < Add || fc > Storm {
+		return nil, errors.New(fmt.Sprintf("%d is not a valid FileChange", fc))
+	}
+	return []byte(fc.String()), nil
+}
+
+func (fc *FileChange) UnmarshalText(text []byte) (err error) {
+	*fc, err = ParseFileChange(string(text))
+	return
+}
+
+// ParseFileChange returns the FileChange with the name, as returned by String
+func ParseFileChange(name string) (fc FileChange, err error) {
+	for fc = Add; fc <= Storm; fc++ {
+		if fc.String() == name {
+			return
+		}
+	}
+	return 0, errors.New(fmt.Sprintf("%s is not a valid FileChange", name))
+}
+
+// eventJSON is the JSON form of a FileEvent
+type eventJSON struct {
+	Version              int           `json:"version"`
+	Kind                 FileChange    `json:"kind"`
+	Path                 string        `json:"path,omitempty"`
+	PreviousPath         string        `json:"previousPath,omitempty"`
+	Description          string        `json:"description,omitempty"`
+	Root                 string        `json:"root,omitempty"`
+	RelativePath         string        `json:"relativePath,omitempty"`
+	PreviousRelativePath string        `json:"previousRelativePath,omitempty"`
+	DetectedAt           *time.Time    `json:"detectedAt,omitempty"`
+	ModTime              *time.Time    `json:"modTime,omitempty"`
+	Cycle                uint64        `json:"cycle,omitempty"`
+	Sequence             uint64        `json:"sequence,omitempty"`
+	Old                  *FileMetadata `json:"old,omitempty"`
+	New                  *FileMetadata `json:"new,omitempty"`
+}
+
+// Times are left out of the JSON when they are not known
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func (fe FileEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventJSON{
+		Version:              EventJSONVersion,
+		Kind:                 fe.FileChange,
+		Path:                 fe.FilePath,
+		PreviousPath:         fe.PreviousPath,
+		Description:          fe.Description,
+		Root:                 fe.Root,
+		RelativePath:         fe.RelativePath,
+		PreviousRelativePath: fe.PreviousRelativePath,
+		DetectedAt:           optionalTime(fe.DetectedAt),
+		ModTime:              optionalTime(fe.ModTime),
+		Cycle:                fe.Cycle,
+		Sequence:             fe.Sequence,
+		Old:                  fe.Old,
+		New:                  fe.New,
+	})
+}
+
+func (fe *FileEvent) UnmarshalJSON(data []byte) (err error) {
+	var decoded eventJSON
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return
+	}
+	if decoded.Version > EventJSONVersion {
+		return errors.New(fmt.Sprintf("version %d of the FileEvent encoding is not supported", decoded.Version))
+	}
+
+	*fe = FileEvent{
+		FileChange:           decoded.Kind,
+		FilePath:             decoded.Path,
+		PreviousPath:         decoded.PreviousPath,
+		Description:          decoded.Description,
+		Root:                 decoded.Root,
+		RelativePath:         decoded.RelativePath,
+		PreviousRelativePath: decoded.PreviousRelativePath,
+		DetectedAt:           timeOrZero(decoded.DetectedAt),
+		ModTime:              timeOrZero(decoded.ModTime),
+		Cycle:                decoded.Cycle,
+		Sequence:             decoded.Sequence,
+		Old:                  decoded.Old,
+		New:                  decoded.New,
+	}
+	return
+}
+
+// MarshalText returns the JSON encoding. Without it the MarshalText method of the embedded FileChange would be
+// used, and the event would be encoded as just its kind.
+func (fe FileEvent) MarshalText() ([]byte, error) {
+	return fe.MarshalJSON()
+}
+
+func (fe *FileEvent) UnmarshalText(text []byte) error {
+	return fe.UnmarshalJSON(text)
+}
+
+// EventWriter writes events as JSON lines, one event per line
+type EventWriter struct {
+	encoder *json.Encoder
+}
+
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{encoder: json.NewEncoder(w)}
+}
+
+func (ew *EventWriter) Write(event FileEvent) error {
+	return ew.encoder.Encode(event)
+}
+
+// EventReader reads events written by an EventWriter
+type EventReader struct {
+	decoder *json.Decoder
+}
+
+func NewEventReader(r io.Reader) *EventReader {
+	return &EventReader{decoder: json.NewDecoder(r)}
+}
+
+// Read returns the next event. io.EOF is returned when there are no more events.
+func (er *EventReader) Read() (event FileEvent, err error) {
+	err = er.decoder.Decode(&event)
+	return
+}
@@ -0,0 +1,135 @@
+package folderWatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFileChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    FileChange
+		wantErr bool
+	}{
+		{name: "Add", want: Add},
+		{name: "Remove", want: Remove},
+		{name: "Write", want: Write},
+		{name: "Move", want: Move},
+		{name: "Overflow", want: Overflow},
+		{name: "Storm", want: Storm},
+		{name: "add", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFileChange(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFileChange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseFileChange() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FileChange(99).MarshalText(); err == nil {
+		t.Error("MarshalText() should return an error for an invalid FileChange")
+	}
+}
+
+func TestFileEvent_JSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		event FileEvent
+		want  string
+	}{
+		{name: "add", event: FileEvent{FileChange: Add, FilePath: "/data/a.txt", Description: "/data/a.txt created"},
+			want: `{"version":1,"kind":"Add","path":"/data/a.txt","description":"/data/a.txt created"}`},
+		{name: "move", event: FileEvent{FileChange: Move, FilePath: "/data/b.txt", PreviousPath: "/data/a.txt"},
+			want: `{"version":1,"kind":"Move","path":"/data/b.txt","previousPath":"/data/a.txt"}`},
+		{name: "root", event: FileEvent{FileChange: Move, FilePath: "/data/sub/b.txt", PreviousPath: "/data/a.txt", Root: "/data/sub",
+			RelativePath: "b.txt", PreviousRelativePath: "../a.txt"},
+			want: `{"version":1,"kind":"Move","path":"/data/sub/b.txt","previousPath":"/data/a.txt","root":"/data/sub",` +
+				`"relativePath":"b.txt","previousRelativePath":"../a.txt"}`},
+		{name: "times and numbers", event: FileEvent{FileChange: Write, FilePath: "/data/a.txt",
+			DetectedAt: time.Date(2020, 12, 30, 10, 0, 1, 500, time.UTC), ModTime: time.Date(2020, 12, 30, 10, 0, 0, 0, time.UTC),
+			Cycle: 3, Sequence: 42},
+			want: `{"version":1,"kind":"Write","path":"/data/a.txt","detectedAt":"2020-12-30T10:00:01.0000005Z",` +
+				`"modTime":"2020-12-30T10:00:00Z","cycle":3,"sequence":42}`},
+		{name: "metadata", event: FileEvent{FileChange: Remove, FilePath: "/data/a.txt",
+			Old: &FileMetadata{Size: 10, Mode: 0640, ModTime: time.Date(2020, 12, 30, 10, 0, 0, 0, time.UTC), Inode: 7, Device: 2049, Uid: 1000, Gid: -1}},
+			want: `{"version":1,"kind":"Remove","path":"/data/a.txt","old":{"size":10,"mode":416,"modTime":"2020-12-30T10:00:00Z",` +
+				`"inode":7,"device":2049,"uid":1000,"gid":-1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.event)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if string(encoded) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", encoded, tt.want)
+			}
+
+			var decoded FileEvent
+			if err = json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatal(err.Error())
+			}
+			if !reflect.DeepEqual(decoded, tt.event) {
+				t.Errorf("json.Unmarshal() = %v, want %v", decoded, tt.event)
+			}
+
+			// the text encoding is the whole event, not just the kind promoted from FileChange
+			text, _ := tt.event.MarshalText()
+			if string(text) != tt.want {
+				t.Errorf("MarshalText() = %s, want %s", text, tt.want)
+			}
+		})
+	}
+
+	var event FileEvent
+	if err := json.Unmarshal([]byte(`{"version":2,"kind":"Add"}`), &event); err == nil {
+		t.Error("json.Unmarshal() should return an error for a newer version")
+	}
+	if err := json.Unmarshal([]byte(`{"version":1,"kind":"Created"}`), &event); err == nil {
+		t.Error("json.Unmarshal() should return an error for an unknown kind")
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	events := []FileEvent{
+		{FileChange: Add, FilePath: "/data/a.txt"},
+		{FileChange: Move, FilePath: "/data/b.txt", PreviousPath: "/data/a.txt"},
+		{FileChange: Remove, FilePath: "/data/b.txt", Old: &FileMetadata{Size: 1, Mode: os.ModeSymlink | 0777, Uid: -1, Gid: -1}},
+	}
+	var buffer bytes.Buffer
+	writer := NewEventWriter(&buffer)
+	for _, event := range events {
+		if err := writer.Write(event); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if lineCount := strings.Count(buffer.String(), "\n"); lineCount != len(events) {
+		t.Errorf("should have written %d lines, got %d", len(events), lineCount)
+	}
+
+	reader := NewEventReader(&buffer)
+	for _, want := range events {
+		got, err := reader.Read()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Read() = %v, want %v", got, want)
+		}
+	}
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("Read() should return io.EOF after the last event, got %v", err)
+	}
+}
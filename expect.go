@@ -0,0 +1,114 @@
+package folderWatcher
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// A default ttl for Expect, long enough for two scans at the maximum calculated interval. A longer IntervalOverride
+// or a pause needs a longer ttl.
+const DefaultExpectationTTL = 2 * MaximumIntervalTime * time.Millisecond
+
+// Number of scans an expectation registered by WriteFile remains valid for. The scan in progress when the file is
+// written may have listed its folder already, so the change may only be found by the next one.
+const writeFileExpectationScans = 2
+
+type expectation struct {
+	path string
+	kind FileChange
+	// the expectation ends at the expiry time, or once the number of scans has completed. Zero values are not used.
+	expires   time.Time
+	scansLeft int
+}
+
+// expectationList holds the changes this process expects to make, so the matching events can be swallowed
+type expectationList struct {
+	mutex        sync.Mutex
+	expectations []*expectation
+}
+
+func newExpectationList() *expectationList {
+	return &expectationList{}
+}
+
+func (el *expectationList) add(e expectation) *expectation {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+	el.expectations = append(el.expectations, &e)
+	return &e
+}
+
+// Remove an expectation which is no longer needed, such as one for a change which could not be made
+func (el *expectationList) remove(e *expectation) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+	for i, existing := range el.expectations {
+		if existing == e {
+			el.expectations = append(el.expectations[:i], el.expectations[i+1:]...)
+			return
+		}
+	}
+}
+
+// Remove the expectation matching the event and report if one was found. Expired expectations are discarded.
+func (el *expectationList) consume(event FileEvent, now time.Time) (matchFound bool) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	remaining := el.expectations[:0]
+	for _, e := range el.expectations {
+		if !e.expires.IsZero() && now.After(e.expires) {
+			continue
+		}
+		if !matchFound && e.path == event.FilePath && e.kind == event.FileChange {
+			matchFound = true
+			continue
+		}
+		remaining = append(remaining, e)
+	}
+	el.expectations = remaining
+	return
+}
+
+// Count a completed scan against the expectations which end after a number of scans, and remove those which have
+// ended
+func (el *expectationList) scanCompleted() {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	remaining := el.expectations[:0]
+	for _, e := range el.expectations {
+		if e.scansLeft > 0 {
+			e.scansLeft--
+			if e.scansLeft == 0 {
+				continue
+			}
+		}
+		remaining = append(remaining, e)
+	}
+	el.expectations = remaining
+}
+
+// Expect registers a change this process is about to make. The next event for the path with the same kind of
+// change is not sent, provided it is detected before the ttl runs out.
+func (w *Watcher) Expect(path string, kind FileChange, ttl time.Duration) {
+	w.expectations.add(expectation{path: AbsPath(path), kind: kind, expires: w.Clock.Now().Add(ttl)})
+}
+
+// WriteFile writes data to the file like ioutil.WriteFile, and expects the resulting Add or Write event so it is
+// not sent to the consumers. The event is expected for the next two scans, however long the interval or a pause
+// lasts. If the file cannot be written, nothing is expected.
+func (w *Watcher) WriteFile(path string, data []byte, perm os.FileMode) (err error) {
+	kind := Add
+	if IsValidPath(path) {
+		kind = Write
+	}
+	// the expectation is registered first, as a scan may find the change before ioutil.WriteFile returns
+	e := w.expectations.add(expectation{path: AbsPath(path), kind: kind, scansLeft: writeFileExpectationScans})
+	if err = ioutil.WriteFile(path, data, perm); err != nil {
+		w.expectations.remove(e)
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package folderWatcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestExpectationList_Consume(t *testing.T) {
+	now := time.Now()
+	el := newExpectationList()
+	el.add(expectation{path: "/folder/a.txt", kind: Write, expires: now.Add(time.Second)})
+	el.add(expectation{path: "/folder/b.txt", kind: Add, expires: now.Add(-time.Second)})
+
+	if el.consume(FileEvent{FileChange: Add, FilePath: "/folder/a.txt"}, now) {
+		t.Error("an event with a different kind of change should not match the expectation")
+	}
+	if el.consume(FileEvent{FileChange: Add, FilePath: "/folder/b.txt"}, now) {
+		t.Error("an expired expectation should not match")
+	}
+	if !el.consume(FileEvent{FileChange: Write, FilePath: "/folder/a.txt"}, now) {
+		t.Error("the expected event should match")
+	}
+	// the expectation is only used once
+	if el.consume(FileEvent{FileChange: Write, FilePath: "/folder/a.txt"}, now) {
+		t.Error("the expectation should only match one event")
+	}
+	if len(el.expectations) != 0 {
+		t.Errorf("all expectations should have been removed, %d remain", len(el.expectations))
+	}
+}
+
+func TestExpectationList_ScanCompleted(t *testing.T) {
+	el := newExpectationList()
+	el.add(expectation{path: "/folder/a.txt", kind: Write, scansLeft: 2})
+	el.add(expectation{path: "/folder/b.txt", kind: Write, expires: time.Now().Add(time.Hour)})
+
+	el.scanCompleted()
+	if len(el.expectations) != 2 {
+		t.Errorf("both expectations should remain after 1 scan, %d remain", len(el.expectations))
+	}
+	el.scanCompleted()
+	if len(el.expectations) != 1 || el.expectations[0].path != "/folder/b.txt" {
+		t.Errorf("only the expectation with an expiry time should remain after 2 scans, got %d", len(el.expectations))
+	}
+}
+
+// The change made by WriteFile is expected for the next scans, however long it takes until they run
+func TestWatcher_WriteFileLongInterval(t *testing.T) {
+	folderPath := t.TempDir()
+	watcher := New()
+	clock := folderwatchertest.NewClock(time.Now())
+	watcher.Clock = clock
+	watcher.IntervalOverride = 60000
+	if err := watcher.AddFolder(folderPath, false, false); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := watcher.WriteFile(filepath.Join(folderPath, "out.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	clock.Advance(time.Minute)
+	if events, _ := watcher.ScanNow(context.Background()); len(events) != 0 {
+		t.Errorf("ScanNow() should not return the change made by WriteFile, got %v", events)
+	}
+}
+
+// Changes made with WriteFile should not be reported, while changes made by others should be
+func TestWatcher_WriteFile(t *testing.T) {
+	watcher, _, receivedEvents, stopWatcher := startCountingWatcher(t)
+
+	newFilePath := randomizedFilePath(filepath.Join(testSubFolder2, "writefile#.txt"))
+	defer removeFiles(false, newFilePath)
+	// each change has its own modification time, as the file may be written within the file system's timestamp
+	// resolution
+	modTime := time.Now()
+	changeModTime := func() {
+		modTime = modTime.Add(time.Second)
+		if err := os.Chtimes(newFilePath, modTime, modTime); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if err := watcher.WriteFile(newFilePath, []byte("new file"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	_, _ = watcher.ScanNow(context.Background())
+	if err := watcher.WriteFile(newFilePath, []byte("updated by the watcher"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	changeModTime()
+	_, _ = watcher.ScanNow(context.Background())
+	writeToFile(newFilePath, "updated by someone else")
+	changeModTime()
+	_, _ = watcher.ScanNow(context.Background())
+	stopWatcher()
+
+	if receivedEvents[Add] != 0 {
+		t.Errorf("should have received 0 Add events, got %d", receivedEvents[Add])
+	}
+	if receivedEvents[Write] != 1 {
+		t.Errorf("should have received 1 Write event, got %d", receivedEvents[Write])
+	}
+}
+
+// A file which could not be written is not expected, so a change made later by someone else is reported
+func TestWatcher_WriteFileError(t *testing.T) {
+	folderPath := t.TempDir()
+	watcher := New()
+	if err := watcher.AddFolder(folderPath, true, false); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	filePath := filepath.Join(folderPath, "missing", "out.txt")
+	if err := watcher.WriteFile(filePath, []byte("data"), 0644); err == nil {
+		t.Fatal("WriteFile() should return an error when the folder does not exist")
+	}
+	if err := os.Mkdir(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err.Error())
+	}
+	writeToFile(filePath, "written by someone else")
+	if events, _ := watcher.ScanNow(context.Background()); len(events) != 1 || events[0].FileChange != Add {
+		t.Errorf("ScanNow() should return the Add event, got %v", events)
+	}
+}
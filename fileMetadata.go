@@ -0,0 +1,31 @@
+package folderWatcher
+
+import (
+	"os"
+	"time"
+)
+
+// FileMetadata is the state of a file when it was scanned. Values which are not available on the operating system
+// or file system are 0, or -1 for Uid and Gid.
+type FileMetadata struct {
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+	Inode   uint64      `json:"inode,omitempty"`
+	Device  uint64      `json:"device,omitempty"`
+	Uid     int         `json:"uid"`
+	Gid     int         `json:"gid"`
+}
+
+// Copy the metadata out of the file info, so the file info does not need to be kept with the event
+func newFileMetadata(fileInfo os.FileInfo) *FileMetadata {
+	metadata := &FileMetadata{
+		Size:    fileInfo.Size(),
+		Mode:    fileInfo.Mode(),
+		ModTime: fileInfo.ModTime(),
+		Uid:     -1,
+		Gid:     -1,
+	}
+	addSystemMetadata(metadata, fileInfo)
+	return metadata
+}
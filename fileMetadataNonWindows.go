@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package folderWatcher
+
+import (
+	"os"
+	"syscall"
+)
+
+// Add the inode, device and owner from the operating system's file information, when it is available
+func addSystemMetadata(metadata *FileMetadata, fileInfo os.FileInfo) {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	metadata.Inode = uint64(stat.Ino)
+	metadata.Device = uint64(stat.Dev)
+	metadata.Uid = int(stat.Uid)
+	metadata.Gid = int(stat.Gid)
+}
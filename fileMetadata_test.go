@@ -0,0 +1,58 @@
+package folderWatcher
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestScanNow_FileMetadata(t *testing.T) {
+	testFilePath := createTestFiles(testSubFolder2, 1)[0]
+	defer removeFiles(false, testFilePath)
+	watcher := New()
+	_ = watcher.AddFolder(testSubFolder2, false, false)
+
+	// make sure the modification time changes
+	time.Sleep(10 * time.Millisecond)
+	if err := ioutil.WriteFile(testFilePath, []byte("longer content"), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+	events, _ := watcher.ScanNow(context.Background())
+	if len(events) != 1 || events[0].FileChange != Write {
+		t.Fatalf("ScanNow() should return 1 Write event, got %v", events)
+	}
+
+	event := events[0]
+	if event.Old == nil || event.New == nil {
+		t.Fatal("a Write event should have Old and New metadata")
+	}
+	if event.New.Size != int64(len("longer content")) || event.Old.Size == event.New.Size {
+		t.Errorf("the sizes should be different, got Old.Size %d and New.Size %d", event.Old.Size, event.New.Size)
+	}
+	if !event.New.ModTime.Equal(event.ModTime) || !event.New.ModTime.After(event.Old.ModTime) {
+		t.Errorf("New.ModTime should be the event ModTime, after Old.ModTime %s, got %s", event.Old.ModTime, event.New.ModTime)
+	}
+	if !event.New.Mode.IsRegular() {
+		t.Errorf("New.Mode should be a regular file, got %s", event.New.Mode)
+	}
+	if runtime.GOOS != "windows" {
+		if event.New.Inode == 0 || event.New.Inode != event.Old.Inode {
+			t.Errorf("the inode should be the same and not 0, got Old.Inode %d and New.Inode %d", event.Old.Inode, event.New.Inode)
+		}
+		if event.New.Uid < 0 || event.New.Gid < 0 {
+			t.Errorf("the owner should be known, got Uid %d and Gid %d", event.New.Uid, event.New.Gid)
+		}
+	}
+
+	// only the metadata before the file was removed is available
+	removeFiles(true, testFilePath)
+	events, _ = watcher.ScanNow(context.Background())
+	if len(events) != 1 || events[0].Old == nil || events[0].New != nil {
+		t.Fatalf("ScanNow() should return 1 Remove event with only Old metadata, got %v", events)
+	}
+	if events[0].Old.Size != event.New.Size {
+		t.Errorf("Old.Size of the Remove event should be %d, got %d", event.New.Size, events[0].Old.Size)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package folderWatcher
+
+import "os"
+
+// Windows does not provide the file index or owner without opening the file, so only the common metadata is used
+func addSystemMetadata(metadata *FileMetadata, fileInfo os.FileInfo) {}
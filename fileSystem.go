@@ -3,6 +3,7 @@ package folderWatcher
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -35,25 +36,95 @@ func AbsPath(path string) (absPath string) {
 }
 
 func GetFileList(folderPath string, recursive bool, showHidden bool) (fileList map[string]os.FileInfo, err error){
+	return getFileList(OSFileSystem{}, NopLogger{}, folderPath, recursive, showHidden)
+}
+
+// FileSystem is the file system scanned by a Watcher. Names are paths in the form used by the operating system,
+// rather than the unrooted paths normally used with io/fs.
+type FileSystem interface {
+	fs.StatFS
+	fs.ReadDirFS
+	// SameFile reports whether the two files are the same file, even if their paths are different. It is used to
+	// detect files which have moved.
+	SameFile(fi1, fi2 fs.FileInfo) bool
+}
+
+// OSFileSystem is the FileSystem for the operating system's files. This is the default for a new Watcher.
+type OSFileSystem struct{}
+
+func (OSFileSystem) Open(name string) (fs.File, error) {return os.Open(name)}
+
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error) {return os.Stat(name)}
+
+func (OSFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {return os.ReadDir(name)}
+
+func (OSFileSystem) SameFile(fi1, fi2 fs.FileInfo) bool {return os.SameFile(fi1, fi2)}
+
+// Check if the path is a folder in the file system
+func isValidDirPathIn(fsys FileSystem, path string) bool {
+	path = strings.Trim(path, " ")
+	if len(path) == 0 {
+		return false
+	}
+
+	fileItem, err := fsys.Stat(path)
+	return err == nil && fileItem.IsDir()
+}
+
+// Check if the path is a file or folder in the file system
+func isValidPathIn(fsys FileSystem, path string) bool {
+	path = strings.Trim(path, " ")
+	if len(path) == 0 {
+		return false
+	}
+
+	_, err := fsys.Stat(path)
+	return err == nil
+}
+
+// Get the files in a folder of the file system. Subfolders are only read when recursive is set.
+func getFileList(fsys FileSystem, logger Logger, folderPath string, recursive bool, showHidden bool) (fileList map[string]os.FileInfo, err error){
 	// make sure the path provided is valid
-	if !IsValidDirPath(folderPath){
+	if !isValidDirPathIn(fsys, folderPath){
 		err = errors.New(fmt.Sprintf("%s is not a valid folder path", folderPath))
 		return
 	}
 	fileList = make(map[string]os.FileInfo)
+	err = addFolderFiles(fsys, logger, folderPath, recursive, showHidden, fileList)
+	return
+}
+
+func addFolderFiles(fsys FileSystem, logger Logger, folderPath string, recursive bool, showHidden bool, fileList map[string]os.FileInfo) (err error){
+	entries, err := fsys.ReadDir(folderPath)
+	if err != nil {
+		return
+	}
 
-	err = filepath.Walk(folderPath, func(filePath string, fileInfo os.FileInfo, err error) error{
-		if err != nil{
-			println(err.Error())
-			return err
+	for _, entry := range entries {
+		filePath := filepath.Join(folderPath, entry.Name())
+		if entry.IsDir() {
+			if recursive {
+				if err = addFolderFiles(fsys, logger, filePath, recursive, showHidden, fileList); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return
+				} else if err != nil {
+					logger.Debug("skipped folder removed while scanning", "path", filePath)
+				}
+				err = nil
+			}
+			continue
+		}
+
+		fileInfo, infoErr := entry.Info()
+		if infoErr != nil {
+			// the file was removed after the folder was read
+			logger.Debug("skipped file removed while scanning", "path", filePath, "error", infoErr)
+			continue
 		}
 
 		// check if the file is hidden before adding it
-		if !fileInfo.IsDir() && (showHidden || !isHiddenFile(filePath)) && (recursive || filepath.Dir(filePath) == folderPath) {
+		if showHidden || !isHiddenFile(filePath) {
 			fileList[filePath] = fileInfo
 		}
-		return nil
-	})
-
+	}
 	return
 }
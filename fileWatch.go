@@ -0,0 +1,83 @@
+package folderWatcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AddFile watches a single file. The watch is for the path rather than the file, so it continues when the file is
+// replaced, for example by renaming a new version over it, or when it is deleted and created again.
+func (w *Watcher) AddFile(path string) (err error) {
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	fileInfo, statErr := w.FileSystem.Stat(path)
+	if statErr != nil || fileInfo.IsDir() {
+		err = errors.New(fmt.Sprintf("%s is not a valid file path", path))
+		return
+	}
+
+	w.fileWatches[path] = true
+	w.addUpdateWatchedFile(path, fileInfo)
+	w.Logger.Debug("watch added", "path", path)
+	w.Observer.WatchAdded(path)
+	w.updateRootStatus(nil)
+	return
+}
+
+// RemoveFile stops watching a file added with AddFile. The file stays watched if a folder watch includes it.
+func (w *Watcher) RemoveFile(path string, returnErrorIfNotFound bool) (err error) {
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	path, err = filepath.Abs(path)
+	if !w.fileWatches[path] {
+		if returnErrorIfNotFound {
+			err = errors.New(fmt.Sprintf("Cannot remove %s because it is not a watched file", path))
+		}
+		return
+	}
+
+	delete(w.fileWatches, path)
+	if _, isStillWatched := findWatchRoot(w.RequestedWatches, path); !isStillWatched {
+		w.removeWatchedFile(path)
+	}
+	w.Logger.Debug("watch removed", "path", path)
+	w.Observer.WatchRemoved(path)
+	w.updateRootStatus(nil)
+	return
+}
+
+// Add the watched files which currently exist to the file list
+func (w *Watcher) addWatchedFiles(fileList map[string]os.FileInfo) {
+	for filePath := range w.fileWatches {
+		if _, found := fileList[filePath]; found {
+			continue
+		}
+		if fileInfo, err := w.FileSystem.Stat(filePath); err == nil && !fileInfo.IsDir() {
+			fileList[filePath] = fileInfo
+		}
+	}
+}
+
+// Find the watched files which have been replaced by a different file with the same modification time, such as a
+// copy renamed over them. compareFileLists only checks the modification time, so it misses these changes.
+func (w *Watcher) findReplacedFiles(previousFiles map[string]os.FileInfo, currentFiles map[string]os.FileInfo) (events []FileEvent) {
+	for filePath := range w.fileWatches {
+		previousFile, wasFound := previousFiles[filePath]
+		currentFile, isFound := currentFiles[filePath]
+		if !wasFound || !isFound || !currentFile.ModTime().Equal(previousFile.ModTime()) || w.FileSystem.SameFile(previousFile, currentFile) {
+			continue
+		}
+		events = append(events, FileEvent{FileChange: Write, FilePath: filePath, ModTime: currentFile.ModTime(),
+			Old: newFileMetadata(previousFile), New: newFileMetadata(currentFile),
+			Description: fmt.Sprintf("%s updated", filePath)})
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package folderWatcher
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestWatcher_AddFile(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/etc/app/config.yaml", []byte("a: 1"))
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "file", path: "/etc/app/config.yaml", wantErr: false},
+		{name: "folder", path: "/etc/app", wantErr: true},
+		{name: "missing file", path: "/etc/app/missing.yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := New()
+			watcher.FileSystem = fsys
+			if err := watcher.AddFile(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("AddFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A file passed to AddFolder is rejected without being watched
+func TestWatcher_AddFolderFile(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/etc/app/config.yaml", []byte("a: 1"))
+	watcher := New()
+	watcher.FileSystem = fsys
+	err := watcher.AddFolder("/etc/app/config.yaml", false, false)
+	if err == nil || !strings.Contains(err.Error(), "AddFile") {
+		t.Errorf("AddFolder() should return an error pointing to AddFile, got %v", err)
+	}
+	if watches := watcher.Watches(); len(watches) != 0 {
+		t.Errorf("AddFolder() should not watch a file, got %v", watches)
+	}
+}
+
+func TestScanNow_WatchedFile(t *testing.T) {
+	configPath := AbsPath("/etc/app/config.yaml")
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile(configPath, []byte("a: 1"))
+	fsys.WriteFile("/etc/app/other.yaml", []byte("b: 1"))
+	watcher := New()
+	watcher.FileSystem = fsys
+	if err := watcher.AddFile(configPath); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		name        string
+		changeFiles func()
+		wantChanges []FileChange
+	}{
+		{name: "other file", changeFiles: func() { fsys.WriteFile("/etc/app/other.yaml", []byte("b: 2")) }},
+		{name: "write", changeFiles: func() { fsys.WriteFile(configPath, []byte("a: 2")) }, wantChanges: []FileChange{Write}},
+		{name: "atomic replace", changeFiles: func() {
+			fsys.WriteFile("/etc/app/config.yaml.tmp", []byte("a: 3"))
+			_ = fsys.Rename("/etc/app/config.yaml.tmp", configPath)
+		}, wantChanges: []FileChange{Write}},
+		{name: "replace with the same modification time", changeFiles: func() {
+			info, _ := fsys.Stat(configPath)
+			fsys.WriteFile("/etc/app/config.yaml.tmp", []byte("a: 4"))
+			_ = fsys.Chtimes("/etc/app/config.yaml.tmp", info.ModTime())
+			_ = fsys.Rename("/etc/app/config.yaml.tmp", configPath)
+		}, wantChanges: []FileChange{Write}},
+		{name: "delete", changeFiles: func() { _ = fsys.Remove(configPath) }, wantChanges: []FileChange{Remove}},
+		{name: "create again", changeFiles: func() { fsys.WriteFile(configPath, []byte("a: 5")) }, wantChanges: []FileChange{Add}},
+		{name: "delete and create before the scan", changeFiles: func() {
+			_ = fsys.Remove(configPath)
+			fsys.WriteFile(configPath, []byte("a: 6"))
+		}, wantChanges: []FileChange{Write}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.changeFiles()
+			events, _ := watcher.ScanNow(context.Background())
+			if len(events) != len(tt.wantChanges) {
+				t.Fatalf("ScanNow() should return %d events, got %v", len(tt.wantChanges), events)
+			}
+			for i, event := range events {
+				if event.FileChange != tt.wantChanges[i] || event.FilePath != configPath {
+					t.Errorf("want %s event for %s, got %s event for %s", tt.wantChanges[i], configPath, event.FileChange, event.FilePath)
+				}
+				if event.Root != configPath || event.RelativePath != filepath.Base(configPath) {
+					t.Errorf("want Root %s and RelativePath %s, got %s and %s", configPath, filepath.Base(configPath), event.Root, event.RelativePath)
+				}
+			}
+		})
+	}
+
+	// removing the file watch stops the file being scanned
+	if err := watcher.RemoveFile(configPath, true); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := watcher.RemoveFile(configPath, true); err == nil {
+		t.Error("RemoveFile() should return an error when the file is not watched")
+	}
+	fsys.WriteFile(configPath, []byte("a: 7"))
+	if events, _ := watcher.ScanNow(context.Background()); len(events) != 0 {
+		t.Errorf("ScanNow() should not return events after RemoveFile, got %v", events)
+	}
+}
+
+// A file in a watched folder stays watched when its file watch is removed, and the other way round
+func TestWatcher_RemoveFileInWatchedFolder(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	watcher := New()
+	watcher.FileSystem = fsys
+	_ = watcher.AddFolder("/data", false, false)
+	_ = watcher.AddFile("/data/a.txt")
+
+	_ = watcher.RemoveFolder("/data", true)
+	if len(watcher.watchedFiles) != 1 {
+		t.Errorf("the file should still be watched after RemoveFolder, got %d watched files", len(watcher.watchedFiles))
+	}
+
+	_ = watcher.AddFolder("/data", false, false)
+	_ = watcher.RemoveFile("/data/a.txt", true)
+	if len(watcher.watchedFiles) != 1 {
+		t.Errorf("the file should still be watched after RemoveFile, got %d watched files", len(watcher.watchedFiles))
+	}
+}
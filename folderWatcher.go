@@ -1,11 +1,13 @@
 package folderWatcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -25,12 +27,20 @@ type WatchRequest struct {
 // constants to represent the state of the watcher
 const (NotStarted WatcherState = 1
 	Running WatcherState = 2
-	Stopped WatcherState =3 )
+	Stopped WatcherState =3
+	Paused WatcherState = 4
+	// only reported by Status, for a running watcher
+	Degraded WatcherState = 5
+	Scanning WatcherState = 6 )
 type WatcherState int
 
 func (ws WatcherState) String() string {
-	stateStrings:= [...]string{"Not Started", "Started", "Stopped"}
-	return stateStrings[ws]
+	stateStrings:= [...]string{"Not Started", "Running", "Stopped", "Paused", "Degraded", "Scanning"}
+	// the states start at 1
+	if ws < NotStarted || int(ws) > len(stateStrings) {
+		return fmt.Sprintf("WatcherState(%d)", int(ws))
+	}
+	return stateStrings[ws-1]
 }
 
 func (ws *WatcherState) ToString() string {
@@ -41,11 +51,58 @@ func (ws *WatcherState) ToString() string {
 type Watcher struct {
 	RequestedWatches map[string]WatchRequest
 	Interval int
+	// when greater than 0, this number of milliseconds is used for the Interval instead of the calculated value
+	IntervalOverride int
 	watchedFiles map[string]os.FileInfo
 	Stopped chan bool
 	FileChanged chan FileEvent
 	watchedFileMutex *sync.RWMutex
+	// held while the watched files are being refreshed
+	scanMutex *sync.Mutex
 	State WatcherState
+	// guards State, which is read by the service loop while the caller changes it
+	stateMutex *sync.RWMutex
+	// the file system being watched, which is the operating system's files by default
+	FileSystem FileSystem
+	// the time source for the polling cycle
+	Clock Clock
+	// maximum number of events waiting for delivery, used when the watcher is first started
+	QueueSize int
+	// what happens to new events when the queue is full
+	QueuePolicy OverflowPolicy
+	// when either is greater than 0, Add and Write events are withheld until the file's size and modification time
+	// have been the same for SettleScans scans and for SettleDuration, so consumers do not see files still being written
+	SettleScans int
+	SettleDuration time.Duration
+	// when set, settled files are also withheld while a process has them open for writing. Only supported on Linux.
+	SettleCheckOpenWriters bool
+	// when a scan finds more than this number of changes, a Storm event is sent for each folder with changes instead
+	// of the individual events. 0 disables the limit.
+	StormEventsPerScan int
+	// the same as StormEventsPerScan, for the number of changes per second since the previous scan
+	StormEventsPerSecond float64
+	// notified of scans and events, for example to collect metrics
+	Observer Observer
+	// receives diagnostic records, such as the start and end of each scan
+	Logger Logger
+	subscribers *subscriberList
+	// created by Start, guarded by stateMutex
+	queue *eventQueue
+	fsWatches map[string]*fsWatch
+	// files added with AddFile
+	fileWatches map[string]bool
+	suppressedPaths *pathSet
+	expectations *expectationList
+	status *watcherStatus
+	// number of the last completed scan, guarded by scanMutex
+	cycle uint64
+	// sequence number of the last event sent, guarded by scanMutex
+	sequence uint64
+	// when the previous scan completed and if it found a storm of changes, guarded by scanMutex
+	lastScanAt time.Time
+	inStorm bool
+	// files withheld until they settle, by path, guarded by scanMutex
+	settlingFiles map[string]*settlingFile
 }
 
 func New() Watcher {
@@ -56,7 +113,22 @@ func New() Watcher {
 		Stopped: make(chan bool),
 		FileChanged: make(chan FileEvent),
 		watchedFileMutex: &sync.RWMutex{},
+		scanMutex: &sync.Mutex{},
 		State: NotStarted,
+		stateMutex: &sync.RWMutex{},
+		FileSystem: OSFileSystem{},
+		Clock: systemClock{},
+		QueueSize: DefaultQueueSize,
+		QueuePolicy: Block,
+		Observer: NopObserver{},
+		Logger: NopLogger{},
+		subscribers: newSubscriberList(),
+		fsWatches: make(map[string]*fsWatch),
+		fileWatches: make(map[string]bool),
+		suppressedPaths: newPathSet(),
+		expectations: newExpectationList(),
+		status: newWatcherStatus(),
+		settlingFiles: make(map[string]*settlingFile),
 	}
 
 	return *newWatcher
@@ -85,33 +157,68 @@ func calculateInterval(watchedFileCount int) int{
 
 
 func (w *Watcher) updateInterval(){
-	w.Interval = calculateInterval(len(w.watchedFiles))
+	previousInterval := w.Interval
+	if w.IntervalOverride > 0 {
+		w.Interval = w.IntervalOverride
+	} else {
+		w.Interval = calculateInterval(len(w.watchedFiles))
+	}
+	if w.Interval != previousInterval {
+		w.Logger.Debug("interval changed", "previous", previousInterval, "interval", w.Interval)
+	}
 }
 
 func (w *Watcher) AddFolder(path string, recursive bool, showHidden bool) (err error){
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
 	path, err  = filepath.Abs(path)
-	// check that the path is valid, return error if it's not
-	if !IsValidPath(path){
-		err = errors.New(fmt.Sprintf("%s is not a valid path", path))
+	// check that the path is a folder, return error if it's not
+	if !isValidDirPathIn(w.FileSystem, path){
+		if isValidPathIn(w.FileSystem, path){
+			err = errors.New(fmt.Sprintf("%s is not a folder, use AddFile to watch a file", path))
+		} else {
+			err = errors.New(fmt.Sprintf("%s is not a valid path", path))
+		}
 		return
 	}
-	// add the path to the list of watched folders
-	w.RequestedWatches[path] = WatchRequest{Path: path, Recursive: recursive, ShowHidden: showHidden}
 
-	// Add the new set of files to watch
-	newFilesToWatch, err := GetFileList(path, recursive, showHidden)
+	// Add the new set of files to watch. The folder is only watched once its files have been listed.
+	newFilesToWatch, err := getFileList(w.FileSystem, w.Logger, path, recursive, showHidden)
 	if err!=nil {
 		return
 	}
+	// add the path to the list of watched folders
+	w.RequestedWatches[path] = WatchRequest{Path: path, Recursive: recursive, ShowHidden: showHidden}
 
 	// add files to the watchFiles list
 	for p, file := range newFilesToWatch{
 		w.addUpdateWatchedFile(p, file)
 	}
+	w.Logger.Debug("watch added", "path", path, "recursive", recursive, "showHidden", showHidden)
+	w.Observer.WatchAdded(path)
+	w.updateRootStatus(nil)
+	return
+}
+
+// Watches returns a copy of the RequestedWatches, sorted by path, which is safe to use while the watcher is running
+func (w *Watcher) Watches() (watches []WatchRequest){
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	for _, request := range w.RequestedWatches{
+		watches = append(watches, request)
+	}
+	sort.Slice(watches, func(i, k int) bool { return watches[i].Path < watches[k].Path })
 	return
 }
 
+// RemoveFolder stops watching a folder added with AddFolder. Files in the folder stay watched while another watch
+// includes them.
 func (w *Watcher) RemoveFolder(path string, returnErrorIfNotFound bool) ( err error){
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
 	path, err = filepath.Abs(path)
 	if _, found := w.RequestedWatches[path]; !found{
 		// the path was not in the collection
@@ -123,24 +230,34 @@ func (w *Watcher) RemoveFolder(path string, returnErrorIfNotFound bool) ( err er
 		return
 	}
 
-	// get a list of files to remove. Including recursive and hidden files, even though they may not have been included
-	// when the folder was added.
-	watchedFilesToRemove, err := GetFileList(path, true, true)
-	if err!=nil{
-		return
+	delete(w.RequestedWatches, path)
+
+	// Remove the files in the folder which are no longer included by any watch. Files are kept while another watch,
+	// such as one for a parent folder, still includes them.
+	var watchedFilesToRemove []string
+	w.watchedFileMutex.RLock()
+	for p := range w.watchedFiles{
+		if !isWithinFolder(p, path) {
+			continue
+		}
+		if _, isStillWatched := findWatchRoot(w.RequestedWatches, p); !isStillWatched && !w.fileWatches[p] {
+			watchedFilesToRemove = append(watchedFilesToRemove, p)
+		}
 	}
+	w.watchedFileMutex.RUnlock()
 
-	// remove the files from the watchedFiles map
-	for p := range watchedFilesToRemove{
+	for _, p := range watchedFilesToRemove{
 		w.removeWatchedFile(p)
 	}
-	delete(w.RequestedWatches, path)
+	w.Logger.Debug("watch removed", "path", path)
+	w.Observer.WatchRemoved(path)
+	w.updateRootStatus(nil)
 	return
 }
 
-func findMatchingFile(fileToMatch os.FileInfo, fileList map[string]os.FileInfo) (matchFound bool, matchedFilePath string){
+func findMatchingFile(sameFile func(fi1, fi2 os.FileInfo) bool, fileToMatch os.FileInfo, fileList map[string]os.FileInfo) (matchFound bool, matchedFilePath string){
 	for path,watchedFile:= range fileList {
-		if os.SameFile(watchedFile, fileToMatch) {
+		if sameFile(watchedFile, fileToMatch) {
 			//  SameFile check does not work the same on Windows
 			matchedFilePath = path
 			matchFound = true
@@ -150,101 +267,353 @@ func findMatchingFile(fileToMatch os.FileInfo, fileList map[string]os.FileInfo)
 	return
 }
 
-func (w *Watcher) scanForFileEvents() {
+// Check if an event should be sent to the consumers. Events for suppressed paths and expected changes are not sent.
+func (w *Watcher) accept(event FileEvent) bool{
+	return !w.suppressedPaths.containsEvent(event) && !w.expectations.consume(event, w.Clock.Now())
+}
+
+// Queue an event for delivery. Events are only queued once the watcher has been started.
+func (w *Watcher) emit(event FileEvent){
+	if queue := w.getQueue(); queue != nil {
+		queue.push(event)
+	}
+}
+
+// Scan for changes and queue the resulting events. The accepted events are returned.
+func (w *Watcher) scan(ctx context.Context) (events []FileEvent, err error){
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	detectedEvents, err := w.scanForFileEvents(ctx)
+	if err != nil {
+		return
+	}
+	// suppressed and expected changes are dropped as they are found, before their expectations can expire while
+	// the file settles
+	var acceptedEvents []FileEvent
+	for _, event := range detectedEvents{
+		if w.accept(event){
+			acceptedEvents = append(acceptedEvents, event)
+		}
+	}
+	w.expectations.scanCompleted()
+	scannedAt := w.Clock.Now()
+	if w.isSettling() || len(w.settlingFiles) > 0 {
+		acceptedEvents = w.settle(acceptedEvents, scannedAt)
+	}
+
+	// summarize a storm of changes, so the consumers are not overwhelmed
+	isStorm := w.isStorm(len(acceptedEvents), scannedAt)
+	if isStorm {
+		changeCount := len(acceptedEvents)
+		acceptedEvents = w.stormEvents(acceptedEvents)
+		if !w.inStorm {
+			w.Logger.Info("storm started", "cycle", w.cycle, "changes", changeCount, "folders", len(acceptedEvents))
+		}
+	} else if w.inStorm {
+		w.Logger.Info("storm ended", "cycle", w.cycle)
+	}
+	w.inStorm = isStorm
+
+	for _, event := range acceptedEvents{
+		w.sequence++
+		event.Sequence = w.sequence
+		events = append(events, event)
+		w.emit(event)
+		w.Observer.EventEmitted(event)
+	}
+	return
+}
+
+// ScanNow scans the watched folders for changes immediately, rather than waiting for the next cycle, and returns
+// the events found. If the watcher has been started the events are also sent to the consumers. The watched files
+// are not updated if the context is cancelled before the scan completes.
+func (w *Watcher) ScanNow(ctx context.Context) (events []FileEvent, err error){
+	return w.scan(ctx)
+}
+
+// Flush waits for a scan in progress to complete and for all of the queued events to be delivered
+func (w *Watcher) Flush(){
+	// wait for the scan to release the lock
+	w.scanMutex.Lock()
+	w.scanMutex.Unlock()
+
+	if queue := w.getQueue(); queue != nil {
+		queue.wait()
+	}
+}
+
+// Send an event to the subscribers and the FileChanged channel. Setting FileChanged to nil stops events being sent
+// to the channel, which is useful when all consumers use Subscribe.
+func (w *Watcher) deliver(event FileEvent){
+	w.subscribers.publish(event)
+	if w.FileChanged != nil {
+		w.FileChanged <- event
+	}
+}
+
+// Deliver an event and tell the Observer how long it took from detection
+func (w *Watcher) deliverObserved(event FileEvent){
+	w.deliver(event)
+	w.Observer.EventDelivered(event, w.Clock.Now().Sub(event.DetectedAt))
+}
+
+// Delivery loop. Runs for the life of the watcher so events queued before Stop are still delivered.
+func (w *Watcher) deliverEvents(q *eventQueue){
+	for event := range q.events {
+		// let the consumers know that they missed events and should rescan
+		if droppedCount := q.takeDropped(); droppedCount > 0 {
+			w.deliverObserved(overflowEvent(droppedCount, w.Clock.Now()))
+		}
+		w.deliverObserved(event)
+		q.done(1)
+	}
+}
+
+// Compare the files in the watched folders with the watched files, returning an event for each change. The watched
+// files are replaced with the current files. The scanMutex must be held by the caller.
+func (w *Watcher) scanForFileEvents(ctx context.Context) (events []FileEvent, err error) {
+	w.status.setScanning(true)
+	defer w.status.setScanning(false)
+	scanStart := time.Now()
+	stats := ScanStats{Cycle: w.cycle + 1}
+	w.Observer.ScanStarted(stats.Cycle)
+	w.Logger.Debug("scan started", "cycle", stats.Cycle)
+	// the errors reading each watch, by the root folder or file system name
+	rootErrors := make(map[string]error)
+
 	// get a refreshed list of all the files in the watched folders
 	newFileList := make(map[string]os.FileInfo)
 	var newFileChan = make(chan map[string]os.FileInfo, 100)
 
+	requests := make([]WatchRequest, 0, len(w.RequestedWatches))
+	for _, requestedWatch := range w.RequestedWatches {
+		requests = append(requests, requestedWatch)
+	}
+	roots := scanRoots(w.RequestedWatches)
+
 	go func() {
 
-		for _, requestedWatch := range w.RequestedWatches {
-			// start processing by going through all of the flies in the new list
-			fl, err := GetFileList(requestedWatch.Path, requestedWatch.Recursive, requestedWatch.ShowHidden)
+		for _, rootPath := range roots {
+			// stop listing files if the scan has been cancelled
+			if ctx.Err() != nil {
+				break
+			}
+			// list the files of every watch below the root, so overlapping watches are only read once
+			fl, folderCount, err := getWatchedFileList(w.FileSystem, w.Logger, rootPath, requests)
+			stats.FoldersScanned += folderCount
 			if err != nil {
-				fmt.Println(err.Error())
+				stats.Errors++
+				rootErrors[rootPath] = err
+				w.Logger.Warn("cannot read watched folder", "path", rootPath, "error", err)
 			} else {
 				newFileChan <- fl
 			}
+			for requestPath, requestErr := range nestedWatchErrors(w.FileSystem, rootPath, requests, err) {
+				stats.Errors++
+				rootErrors[requestPath] = requestErr
+				w.Logger.Warn("cannot read watched folder", "path", requestPath, "error", requestErr)
+			}
 		}
 		close(newFileChan)
 
 	}()
 
-	movedFiles := make(map[string]string) // oldPath[newPath]
 	// collect the lists of files
 	for fl := range newFileChan{
 		for newFilePath, newFile:= range fl{
 			newFileList[newFilePath] = newFile
-			//fmt.Printf("adding %s\n", newFilePath)
-			existingFile, isExistingFile := w.watchedFiles[newFilePath]
-			if isExistingFile {
-				// The new list and pre-existing list have a matching path.
-				// Check to see if the file has been updated.
-				if newFile.ModTime() != existingFile.ModTime(){
-					w.FileChanged<-FileEvent{FileChange:Write, FilePath: newFilePath,
-						Description: fmt.Sprintf("%s updated", newFilePath)}
-				}
-			} else {
-				// a file in the new list of files was not found in the watchedFiles map. It could be a new file, or
-				// it could be a file which has moved.
-				matchFound, matchPath := findMatchingFile(newFile, w.watchedFiles)
-				if matchFound{
-					movedFiles[matchPath] = newFilePath
-					w.FileChanged <- FileEvent{FileChange: Move,
-						FilePath: newFilePath,
-						PreviousPath: matchPath,
-						Description: fmt.Sprintf("%s move to %s", matchPath, newFilePath)}
-				} else {
-					// The file is in the new list of files, but not the watchedFiles list and the file was not moved.
-					// Process this as a new file.
-					w.FileChanged<- FileEvent{FileChange:Add, FilePath: newFilePath,
-							Description: fmt.Sprintf("%s created", newFilePath)}
-				}
-			}
+		}
+	}
+
+	w.addWatchedFiles(newFileList)
 
+	// list the files in the watched io/fs file systems
+	newFSFileLists := make(map[string]map[string]os.FileInfo)
+	for name, watch := range w.fsWatches {
+		if ctx.Err() != nil {
+			break
+		}
+		fl, folderCount, err := watch.getFileList(w.Logger)
+		stats.FoldersScanned += folderCount
+		if err != nil {
+			stats.Errors++
+			rootErrors[name] = err
+			w.Logger.Warn("cannot read watched file system", "fs", name, "error", err)
+			continue
 		}
+		stats.FilesScanned += len(fl)
+		newFSFileLists[name] = fl
+	}
+
+	// a cancelled scan may not have listed every folder, so the results cannot be used
+	if err = ctx.Err(); err != nil {
+		w.Logger.Debug("scan cancelled", "cycle", stats.Cycle)
+		stats.Duration = time.Since(scanStart)
+		stats.Err = err
+		w.Observer.ScanFinished(stats)
+		return
+	}
+
+	// the files of overlapping watches are merged, so each change is only found once
+	events = compareFileLists(w.FileSystem.SameFile, w.watchedFiles, newFileList)
+	events = append(events, w.findReplacedFiles(w.watchedFiles, newFileList)...)
+	w.setWatchRoots(events)
+	for name, fl := range newFSFileLists {
+		watch := w.fsWatches[name]
+		fsEvents := compareFileLists(watch.sameFile, watch.files, fl)
+		watch.setWatchRoots(fsEvents)
+		events = append(events, fsEvents...)
+		watch.files = fl
+	}
+
+	// every event found by this scan has the same detection time and cycle
+	w.cycle++
+	detectedAt := w.Clock.Now()
+	for i := range events {
+		events[i].DetectedAt = detectedAt
+		events[i].Cycle = w.cycle
+	}
+
+	// replace the watch list with the newly created map
+	w.watchedFileMutex.Lock()
+	w.watchedFiles = newFileList
+	w.watchedFileMutex.Unlock()
+	w.updateInterval()
+
+	stats.Duration = time.Since(scanStart)
+	w.status.setLastScan(w.Clock.Now(), stats.Duration)
+	w.updateRootStatus(rootErrors)
+	stats.FilesScanned += len(newFileList)
+	stats.Events = len(events)
+	stats.Interval = w.Interval
+	if queue := w.getQueue(); queue != nil {
+		stats.QueueDepth = queue.depth()
 	}
+	w.Observer.ScanFinished(stats)
+	w.Logger.Debug("scan finished", "cycle", stats.Cycle, "duration", stats.Duration, "files", stats.FilesScanned,
+		"folders", stats.FoldersScanned, "events", stats.Events, "errors", stats.Errors)
+	return
+}
 
+// Compare the previous and current lists of files and return an event for each difference
+func compareFileLists(sameFile func(fi1, fi2 os.FileInfo) bool, previousFiles map[string]os.FileInfo, currentFiles map[string]os.FileInfo) (events []FileEvent) {
+	movedFiles := make(map[string]string) // oldPath[newPath]
+	for newFilePath, newFile:= range currentFiles{
+		existingFile, isExistingFile := previousFiles[newFilePath]
+		if isExistingFile {
+			// The new list and pre-existing list have a matching path.
+			// Check to see if the file has been updated.
+			if newFile.ModTime() != existingFile.ModTime(){
+				events = append(events, FileEvent{FileChange:Write, FilePath: newFilePath, ModTime: newFile.ModTime(),
+					Old: newFileMetadata(existingFile), New: newFileMetadata(newFile),
+					Description: fmt.Sprintf("%s updated", newFilePath)})
+			}
+		} else {
+			// a file in the new list of files was not found in the watchedFiles map. It could be a new file, or
+			// it could be a file which has moved.
+			matchFound, matchPath := findMatchingFile(sameFile, newFile, previousFiles)
+			if matchFound{
+				movedFiles[matchPath] = newFilePath
+				events = append(events, FileEvent{FileChange: Move,
+					FilePath: newFilePath,
+					PreviousPath: matchPath,
+					ModTime: newFile.ModTime(),
+					Old: newFileMetadata(previousFiles[matchPath]),
+					New: newFileMetadata(newFile),
+					Description: fmt.Sprintf("%s move to %s", matchPath, newFilePath)})
+			} else {
+				// The file is in the new list of files, but not the watchedFiles list and the file was not moved.
+				// Process this as a new file.
+				events = append(events, FileEvent{FileChange:Add, FilePath: newFilePath, ModTime: newFile.ModTime(),
+						New: newFileMetadata(newFile),
+						Description: fmt.Sprintf("%s created", newFilePath)})
+			}
+		}
+	}
 
 	// find deleted files
-	for path:= range w.watchedFiles{
-		_,isInNewFilesList := newFileList[path]
+	for path, existingFile := range previousFiles{
+		_,isInNewFilesList := currentFiles[path]
 		_,isMovedFile := movedFiles[path]
 		if !isInNewFilesList && ! isMovedFile{
-			w.FileChanged<- FileEvent{FileChange: Remove, FilePath: path,
-				Description: fmt.Sprintf("%s deleted", path)}
+			events = append(events, FileEvent{FileChange: Remove, FilePath: path, ModTime: existingFile.ModTime(),
+				Old: newFileMetadata(existingFile),
+				Description: fmt.Sprintf("%s deleted", path)})
 		}
 	}
-	// replace the watch list with the newly created map
-	w.watchedFiles = newFileList
-	w.updateInterval()
+	return
+}
 
+// Get the State while protecting it with a mutex
+func (w *Watcher) getState() WatcherState{
+	w.stateMutex.RLock()
+	defer w.stateMutex.RUnlock()
+	return w.State
+}
+
+// Set the State while protecting it with a mutex
+func (w *Watcher) setState(state WatcherState){
+	w.stateMutex.Lock()
+	w.State = state
+	w.stateMutex.Unlock()
+}
+
+// Get the queue, which is created when the watcher is first started
+func (w *Watcher) getQueue() *eventQueue{
+	w.stateMutex.RLock()
+	defer w.stateMutex.RUnlock()
+	return w.queue
+}
+
+// Get the Interval, which is updated by scans
+func (w *Watcher) getInterval() int{
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+	return w.Interval
 }
 
 // Stop the watcher
 func (w *Watcher) Stop(){
-	w.State = Stopped
+	w.setState(Stopped)
 	w.Stopped<-true
 }
 
 func (w *Watcher) Start(){
-	if w.State == Running{
+	w.stateMutex.Lock()
+	if w.State == Running || w.State == Paused{
 		// If STart is called when the service is already running, do not start another service loop
+		w.stateMutex.Unlock()
 		return
 	}
-
 	w.State = Running
+	if w.queue == nil {
+		w.queue = newEventQueue(w.QueueSize, w.QueuePolicy)
+		go w.deliverEvents(w.queue)
+	}
+	w.stateMutex.Unlock()
+
+	w.scanMutex.Lock()
+	w.updateInterval()
+	w.scanMutex.Unlock()
+
 	// Service loop
 	go func(){
 		for {
-			time.Sleep(time.Duration(w.Interval) * time.Millisecond )
+			<-w.Clock.After(time.Duration(w.getInterval()) * time.Millisecond)
+
+			// do not scan while paused, so the watched files remain as they were when Pause was called
+			state := w.getState()
+			if state == Paused{
+				continue
+			}
 
 			// exit service loop
-			if w.State != Running{
+			if state != Running{
 				break
 			}
 
-			w.scanForFileEvents()
+			_, _ = w.scan(context.Background())
 		}
 
 	}()
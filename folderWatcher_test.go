@@ -1,6 +1,7 @@
 package folderWatcher
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
 )
 
 // template func for all test functions
@@ -628,4 +631,126 @@ func TestCalculateInterval(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// Test scanning on demand, without starting the watcher
+func TestScanNow(t *testing.T) {
+	watcher := New()
+	_ = watcher.AddFolder(testSubFolder2, false, false)
+
+	testFiles := createTestFiles(testSubFolder2, 1)
+	defer removeFiles(false, testFiles...)
+	absTestFilePath := AbsPath(testFiles[0])
+
+	// a cancelled scan should return an error and leave the watched files alone
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := watcher.ScanNow(ctx); err == nil {
+		t.Error("ScanNow() with a cancelled context should return an error")
+	}
+	if _, found := watcher.watchedFiles[absTestFilePath]; found {
+		t.Error("a cancelled scan should not update the watched files")
+	}
+
+	tests := []struct {
+		name       string
+		changeFile func()
+		wantChange FileChange
+	}{
+		{name: "add", changeFile: func() {}, wantChange: Add},
+		{name: "write", changeFile: func() { writeToFile(testFiles[0], "updated") }, wantChange: Write},
+		{name: "remove", changeFile: func() { removeFiles(true, testFiles...) }, wantChange: Remove},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// make sure the modification time changes
+			time.Sleep(10 * time.Millisecond)
+			tt.changeFile()
+
+			events, err := watcher.ScanNow(context.Background())
+			if err != nil {
+				t.Fatalf("ScanNow() error = %v", err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("ScanNow() should return 1 event, got %d", len(events))
+			}
+			if events[0].FileChange != tt.wantChange || events[0].FilePath != absTestFilePath {
+				t.Errorf("ScanNow() want %s event for %s, got %s event for %s", tt.wantChange, absTestFilePath, events[0].FileChange, events[0].FilePath)
+			}
+		})
+	}
+}
+
+// Make sure Flush waits until the events from a scan are delivered
+func TestFlush(t *testing.T) {
+	watcher := New()
+	watcher.FileChanged = nil
+	// the service loop never scans with a fake clock, so only ScanNow finds the files
+	watcher.Clock = folderwatchertest.NewClock(time.Now())
+	_ = watcher.AddFolder(testSubFolder2, false, false)
+	sub, _ := watcher.Subscribe(Filter{}, 0, Block)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	defer watcher.Stop()
+
+	testFiles := createSequentialTestFiles(testSubFolder2, 3)
+	defer removeFiles(false, testFiles...)
+	_, _ = watcher.ScanNow(context.Background())
+	watcher.Flush()
+
+	if len(sub.Events()) != 3 {
+		t.Errorf("all 3 events should have been delivered after Flush(), got %d", len(sub.Events()))
+	}
+}
+
+func TestScanNow_EventTimes(t *testing.T) {
+	clock := folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	fsys := folderwatchertest.NewFS()
+	fsys.Now = clock.Now
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Clock = clock
+	fsys.Mkdir("/data")
+	_ = watcher.AddFolder("/data", false, false)
+
+	tests := []struct {
+		name         string
+		changeFiles  func()
+		wantCycle    uint64
+		wantSequence []uint64
+	}{
+		{name: "add", changeFiles: func() { fsys.WriteFile("/data/a.txt", []byte("a")); fsys.WriteFile("/data/b.txt", []byte("b")) },
+			wantCycle: 1, wantSequence: []uint64{1, 2}},
+		{name: "no changes", changeFiles: func() {}, wantCycle: 2},
+		{name: "suppressed", changeFiles: func() { fsys.WriteFile("/data/a.txt", []byte("a2")); watcher.Suppress("/data/a.txt") },
+			wantCycle: 3},
+		{name: "remove", changeFiles: func() { _ = fsys.Remove("/data/b.txt") }, wantCycle: 4, wantSequence: []uint64{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(time.Second)
+			tt.changeFiles()
+			events, _ := watcher.ScanNow(context.Background())
+			if len(events) != len(tt.wantSequence) {
+				t.Fatalf("ScanNow() should return %d events, got %d", len(tt.wantSequence), len(events))
+			}
+
+			// the order of the events within a scan is not fixed, but the sequence numbers are
+			for i, event := range events {
+				if event.Sequence != tt.wantSequence[i] {
+					t.Errorf("event %d should have Sequence %d, got %d", i, tt.wantSequence[i], event.Sequence)
+				}
+				if event.Cycle != tt.wantCycle {
+					t.Errorf("%s event should have Cycle %d, got %d", event.FileChange, tt.wantCycle, event.Cycle)
+				}
+				if !event.DetectedAt.Equal(clock.Now()) {
+					t.Errorf("%s event should have DetectedAt %s, got %s", event.FileChange, clock.Now(), event.DetectedAt)
+				}
+				if info, err := fsys.Stat(event.FilePath); err == nil && !event.ModTime.Equal(info.ModTime()) {
+					t.Errorf("%s event should have ModTime %s, got %s", event.FileChange, info.ModTime(), event.ModTime)
+				} else if err != nil && event.ModTime.IsZero() {
+					t.Errorf("%s event should have the last ModTime seen", event.FileChange)
+				}
+			}
+		})
+	}
+}
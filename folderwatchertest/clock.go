@@ -0,0 +1,83 @@
+package folderwatchertest
+
+import (
+	"sync"
+	"time"
+)
+
+type waiter struct {
+	until   time.Time
+	channel chan time.Time
+}
+
+// Clock is a manually controlled clock which can be used as the Clock of a Watcher. Time only moves when Advance
+// is called.
+type Clock struct {
+	mutex   sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	waiters []waiter
+}
+
+// NewClock creates a clock set to the time provided
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.changed = sync.NewCond(&c.mutex)
+	return c
+}
+
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After returns a channel which receives the time once the clock has been advanced by at least d
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	channel := make(chan time.Time, 1)
+	if d <= 0 {
+		channel <- c.now
+		return channel
+	}
+	c.waiters = append(c.waiters, waiter{until: c.now.Add(d), channel: channel})
+	c.changed.Broadcast()
+	return channel
+}
+
+// Advance moves the clock forward, releasing the waiters whose time has come
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.until.After(c.now) {
+			remaining = append(remaining, w)
+		} else {
+			w.channel <- c.now
+		}
+	}
+	c.waiters = remaining
+	c.changed.Broadcast()
+}
+
+// BlockUntil waits until at least count goroutines are waiting on channels returned by After. This is used to
+// make sure a watcher is waiting for its next cycle before the clock is advanced.
+func (c *Clock) BlockUntil(count int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for len(c.waiters) < count {
+		c.changed.Wait()
+	}
+}
+
+// Waiters returns the number of goroutines waiting on the clock
+func (c *Clock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.waiters)
+}
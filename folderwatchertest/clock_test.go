@@ -0,0 +1,42 @@
+package folderwatchertest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC)
+	clock := folderwatchertest.NewClock(start)
+
+	fired := clock.After(time.Second)
+	if clock.Waiters() != 1 {
+		t.Errorf("there should be 1 waiter, got %d", clock.Waiters())
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-fired:
+		t.Error("After() should not fire before the duration has passed")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case firedAt := <-fired:
+		if !firedAt.Equal(start.Add(time.Second)) {
+			t.Errorf("After() should send the time it fired, got %v", firedAt)
+		}
+	default:
+		t.Error("After() should fire once the duration has passed")
+	}
+
+	if !clock.Now().Equal(start.Add(time.Second)) {
+		t.Errorf("Now() should be %v, got %v", start.Add(time.Second), clock.Now())
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("there should be no waiters left, got %d", clock.Waiters())
+	}
+}
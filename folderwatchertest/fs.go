@@ -0,0 +1,162 @@
+// Package folderwatchertest provides an in-memory file system and a manual clock for testing code which uses a
+// folderWatcher.Watcher, without touching the disk or waiting for the polling cycle.
+package folderwatchertest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// fileID is stored as the Sys value of each file, so files can be matched after they are renamed
+type fileID uint64
+
+// FS is an in-memory file system which can be used as the FileSystem of a Watcher, or passed to AddFS. Paths may be
+// rooted operating system paths or unrooted io/fs paths; the volume name and leading separator are ignored, so
+// "/data/a.txt" and "data/a.txt" are the same file. Folders are created as files are written.
+type FS struct {
+	mutex  sync.RWMutex
+	files  fstest.MapFS
+	lastID fileID
+	// Now is used for the modification time of written files. It defaults to time.Now and can be set to a Clock's
+	// Now method.
+	Now func() time.Time
+}
+
+// NewFS creates an empty file system
+func NewFS() *FS {
+	return &FS{files: make(fstest.MapFS), Now: time.Now}
+}
+
+// Convert a path to the key used in the map
+func key(name string) string {
+	name = filepath.ToSlash(strings.TrimPrefix(name, filepath.VolumeName(name)))
+	name = path.Clean(strings.TrimLeft(name, "/"))
+	if name == "" || name == "/" {
+		return "."
+	}
+	return name
+}
+
+// Return a modification time which is later than the previous one, so every write can be detected
+func (f *FS) nextModTime(previous *fstest.MapFile) time.Time {
+	modTime := f.Now()
+	if previous != nil && !modTime.After(previous.ModTime) {
+		modTime = previous.ModTime.Add(time.Nanosecond)
+	}
+	return modTime
+}
+
+// WriteFile creates or replaces the contents of a file. A file which already exists keeps its identity, so the
+// change is seen as a Write.
+func (f *FS) WriteFile(name string, data []byte) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	k := key(name)
+	previous := f.files[k]
+	newFile := &fstest.MapFile{Data: append([]byte(nil), data...), Mode: 0644, ModTime: f.nextModTime(previous)}
+	if previous != nil {
+		newFile.Mode = previous.Mode
+		newFile.Sys = previous.Sys
+	} else {
+		f.lastID++
+		newFile.Sys = f.lastID
+	}
+	// files are replaced rather than changed, so FileInfo values already returned do not change
+	f.files[k] = newFile
+}
+
+// Mkdir creates an empty folder, along with any missing parent folders
+func (f *FS) Mkdir(name string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.lastID++
+	f.files[key(name)] = &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: f.Now(), Sys: f.lastID}
+}
+
+// Remove deletes a file, or a folder along with everything in it
+func (f *FS) Remove(name string) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	k := key(name)
+	found := false
+	for filePath := range f.files {
+		if filePath == k || strings.HasPrefix(filePath, k+"/") {
+			delete(f.files, filePath)
+			found = true
+		}
+	}
+	if !found {
+		err = &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	return
+}
+
+// Rename moves a file. The file keeps its identity, so the change is seen as a Move.
+func (f *FS) Rename(oldName string, newName string) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	oldKey := key(oldName)
+	file, found := f.files[oldKey]
+	if !found {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrNotExist}
+	}
+	if file.Mode.IsDir() {
+		return errors.New(fmt.Sprintf("%s is a folder, only files can be renamed", oldName))
+	}
+	delete(f.files, oldKey)
+	f.files[key(newName)] = file
+	return
+}
+
+// Chtimes sets the modification time of a file
+func (f *FS) Chtimes(name string, modTime time.Time) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	k := key(name)
+	file, found := f.files[k]
+	if !found {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	changedFile := *file
+	changedFile.ModTime = modTime
+	f.files[k] = &changedFile
+	return
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.files.Open(key(name))
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.files.Stat(key(name))
+}
+
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.files.ReadDir(key(name))
+}
+
+// SameFile reports whether the two files have the same identity. A file keeps its identity when it is written to
+// or renamed.
+func (f *FS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	id1, ok1 := fi1.Sys().(fileID)
+	id2, ok2 := fi2.Sys().(fileID)
+	return ok1 && ok2 && id1 == id2
+}
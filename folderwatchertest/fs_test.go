@@ -0,0 +1,77 @@
+package folderwatchertest_test
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+var _ folderWatcher.FileSystem = (*folderwatchertest.FS)(nil)
+var _ folderWatcher.Clock = (*folderwatchertest.Clock)(nil)
+
+func TestFS(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	fsys.Mkdir("/data/empty")
+
+	// the file system can be used with the io/fs functions
+	var walkedPaths []string
+	_ = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		walkedPaths = append(walkedPaths, path)
+		return err
+	})
+	wantPaths := []string{".", "data", "data/a.txt", "data/empty", "data/sub", "data/sub/b.txt"}
+	if strings.Join(walkedPaths, ",") != strings.Join(wantPaths, ",") {
+		t.Errorf("fs.WalkDir() visited %v, want %v", walkedPaths, wantPaths)
+	}
+	if data, err := fs.ReadFile(fsys, "data/sub/b.txt"); err != nil || string(data) != "b" {
+		t.Errorf("fs.ReadFile() = %q, %v, want \"b\"", data, err)
+	}
+
+	// rooted and unrooted paths refer to the same file
+	before, err := fsys.Stat("/data/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys.WriteFile("data/a.txt", []byte("updated"))
+	after, _ := fsys.Stat("/data/a.txt")
+	if !after.ModTime().After(before.ModTime()) {
+		t.Error("writing to a file should change the modification time")
+	}
+	if !fsys.SameFile(before, after) {
+		t.Error("a file should keep its identity when it is written to")
+	}
+
+	if err = fsys.Rename("/data/a.txt", "/data/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	renamed, _ := fsys.Stat("/data/sub/c.txt")
+	if !fsys.SameFile(before, renamed) {
+		t.Error("a file should keep its identity when it is renamed")
+	}
+	other, _ := fsys.Stat("/data/sub/b.txt")
+	if fsys.SameFile(before, other) {
+		t.Error("different files should not have the same identity")
+	}
+
+	modTime := time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC)
+	_ = fsys.Chtimes("/data/sub/c.txt", modTime)
+	if changed, _ := fsys.Stat("/data/sub/c.txt"); !changed.ModTime().Equal(modTime) {
+		t.Errorf("Chtimes() should set the modification time to %v, got %v", modTime, changed.ModTime())
+	}
+
+	if err = fsys.Remove("/data/sub"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fsys.Stat("/data/sub/b.txt"); err == nil {
+		t.Error("removing a folder should remove the files in it")
+	}
+	if err = fsys.Remove("/data/sub"); err == nil {
+		t.Error("removing a path which does not exist should return an error")
+	}
+}
@@ -0,0 +1,74 @@
+package folderwatchertest_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+// Run a watcher through a polling cycle without using the disk or waiting
+func TestWatcherWithFakes(t *testing.T) {
+	clock := folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	fsys := folderwatchertest.NewFS()
+	fsys.Now = clock.Now
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+
+	watcher := folderWatcher.New()
+	watcher.FileSystem = fsys
+	watcher.Clock = clock
+	watcher.FileChanged = nil
+	if err := watcher.AddFolder("/data", true, false); err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := watcher.Subscribe(folderWatcher.Filter{}, 0, folderWatcher.Block)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	defer watcher.Stop()
+
+	// wait for the watcher to be waiting for its next cycle, then make changes and run the cycle
+	clock.BlockUntil(1)
+	fsys.WriteFile("/data/c.txt", []byte("c"))
+	_ = fsys.Rename("/data/a.txt", "/data/sub/a.txt")
+	_ = fsys.Remove("/data/sub/b.txt")
+	clock.Advance(time.Duration(watcher.Interval) * time.Millisecond)
+	clock.BlockUntil(1)
+	watcher.Flush()
+
+	receivedEvents := make(map[folderWatcher.FileChange]folderWatcher.FileEvent)
+	for len(sub.Events()) > 0 {
+		event := <-sub.Events()
+		receivedEvents[event.FileChange] = event
+	}
+
+	wantEvents := []struct {
+		change       folderWatcher.FileChange
+		path         string
+		previousPath string
+	}{
+		{folderWatcher.Add, "/data/c.txt", ""},
+		{folderWatcher.Move, "/data/sub/a.txt", "/data/a.txt"},
+		{folderWatcher.Remove, "/data/sub/b.txt", ""},
+	}
+	if len(receivedEvents) != len(wantEvents) {
+		t.Errorf("should have received %d events, got %d", len(wantEvents), len(receivedEvents))
+	}
+	for _, want := range wantEvents {
+		event, found := receivedEvents[want.change]
+		if !found {
+			t.Errorf("did not receive a %s event", want.change)
+			continue
+		}
+		if wantPath, _ := filepath.Abs(want.path); event.FilePath != wantPath {
+			t.Errorf("%s event should be for %s, got %s", want.change, wantPath, event.FilePath)
+		}
+		if want.previousPath != "" {
+			if wantPath, _ := filepath.Abs(want.previousPath); event.PreviousPath != wantPath {
+				t.Errorf("%s event should have previous path %s, got %s", want.change, wantPath, event.PreviousPath)
+			}
+		}
+	}
+}
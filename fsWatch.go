@@ -0,0 +1,137 @@
+package folderWatcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// fsWatch is a watch on an io/fs file system. Its files are kept apart from the operating system's files because
+// the paths are relative to the file system.
+type fsWatch struct {
+	name    string
+	fsys    fs.FS
+	request WatchRequest
+	files   map[string]os.FileInfo
+}
+
+// Use the file system's SameFile method to detect moved files if it has one. Otherwise os.SameFile is used, which
+// works for file systems backed by the disk, such as os.DirFS.
+func (fw *fsWatch) sameFile(fi1, fi2 os.FileInfo) bool {
+	if sameFiler, ok := fw.fsys.(interface{ SameFile(fi1, fi2 fs.FileInfo) bool }); ok {
+		return sameFiler.SameFile(fi1, fi2)
+	}
+	return os.SameFile(fi1, fi2)
+}
+
+// Get the files in the watched folder of the file system, and the number of folders read. Hidden files are those
+// with a name starting with a dot.
+func (fw *fsWatch) getFileList(logger Logger) (fileList map[string]os.FileInfo, folderCount int, err error) {
+	fileList = make(map[string]os.FileInfo)
+	err = fs.WalkDir(fw.fsys, fw.request.Path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if filePath != fw.request.Path && errors.Is(err, fs.ErrNotExist) {
+				// removed while the file system was being read
+				logger.Debug("skipped entry removed while scanning", "fs", fw.name, "path", filePath)
+				return nil
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			if filePath != fw.request.Path && !fw.request.Recursive {
+				return fs.SkipDir
+			}
+			folderCount++
+			return nil
+		}
+
+		if !fw.request.ShowHidden && strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		fileInfo, infoErr := entry.Info()
+		if infoErr != nil {
+			logger.Debug("skipped file removed while scanning", "fs", fw.name, "path", filePath, "error", infoErr)
+			return nil
+		}
+		fileList[filePath] = fileInfo
+		return nil
+	})
+	if err != nil {
+		err = errors.New(fmt.Sprintf("cannot read %s in %s: %s", fw.request.Path, fw.name, err.Error()))
+	}
+	return
+}
+
+// AddFS watches a folder of an io/fs file system, such as os.DirFS, a zip archive or an in-memory file system. The
+// name identifies the watch. The request's Path is the folder within the file system, using io/fs path rules, and
+// the whole file system is watched if it is empty. Events for the file system have paths relative to it.
+func (w *Watcher) AddFS(name string, fsys fs.FS, request WatchRequest) (err error) {
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	if len(strings.TrimSpace(name)) == 0 {
+		err = errors.New("a name is required to watch a file system")
+		return
+	}
+	if _, found := w.fsWatches[name]; found {
+		err = errors.New(fmt.Sprintf("a file system named %s is already being watched", name))
+		return
+	}
+
+	if request.Path == "" {
+		request.Path = "."
+	}
+	request.Path = path.Clean(request.Path)
+	if !fs.ValidPath(request.Path) {
+		err = errors.New(fmt.Sprintf("%s is not a valid path in %s", request.Path, name))
+		return
+	}
+
+	watch := &fsWatch{name: name, fsys: fsys, request: request}
+	if watch.files, _, err = watch.getFileList(w.Logger); err != nil {
+		return
+	}
+	w.fsWatches[name] = watch
+	w.Logger.Debug("watch added", "fs", name, "path", request.Path, "recursive", request.Recursive, "showHidden", request.ShowHidden)
+	w.Observer.WatchAdded(name)
+	w.updateRootStatus(nil)
+	return
+}
+
+// UpdateFS replaces the file system of a watch, for example when an archive has been replaced. The next scan
+// reports the differences between the old and new file systems.
+func (w *Watcher) UpdateFS(name string, fsys fs.FS) (err error) {
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	watch, found := w.fsWatches[name]
+	if !found {
+		err = errors.New(fmt.Sprintf("Cannot update %s because it is not a watched file system", name))
+		return
+	}
+	watch.fsys = fsys
+	return
+}
+
+// RemoveFS stops watching a file system added with AddFS
+func (w *Watcher) RemoveFS(name string, returnErrorIfNotFound bool) (err error) {
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	if _, found := w.fsWatches[name]; !found {
+		if returnErrorIfNotFound {
+			err = errors.New(fmt.Sprintf("Cannot remove %s because it is not a watched file system", name))
+		}
+		return
+	}
+	delete(w.fsWatches, name)
+	w.Logger.Debug("watch removed", "fs", name)
+	w.Observer.WatchRemoved(name)
+	w.updateRootStatus(nil)
+	return
+}
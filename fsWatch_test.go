@@ -0,0 +1,123 @@
+package folderWatcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatcher_AddFS(t *testing.T) {
+	fsys := fstest.MapFS{"data/a.txt": &fstest.MapFile{Data: []byte("a")}}
+	tests := []struct {
+		name    string
+		fsName  string
+		request WatchRequest
+		wantErr bool
+	}{
+		{name: "no name", fsName: " ", request: WatchRequest{}, wantErr: true},
+		{name: "invalid path", fsName: "test", request: WatchRequest{Path: "/data"}, wantErr: true},
+		{name: "missing folder", fsName: "test", request: WatchRequest{Path: "missing"}, wantErr: true},
+		{name: "whole file system", fsName: "test", request: WatchRequest{}, wantErr: false},
+		{name: "folder", fsName: "test", request: WatchRequest{Path: "data"}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := New()
+			if err := watcher.AddFS(tt.fsName, fsys, tt.request); (err != nil) != tt.wantErr {
+				t.Errorf("AddFS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// the same name cannot be used twice
+	watcher := New()
+	_ = watcher.AddFS("test", fsys, WatchRequest{})
+	if err := watcher.AddFS("test", fsys, WatchRequest{}); err == nil {
+		t.Error("AddFS() should return an error when the name is already used")
+	}
+}
+
+func TestWatcher_ScanFS(t *testing.T) {
+	modTime := time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"data/a.txt":       &fstest.MapFile{Data: []byte("a"), ModTime: modTime},
+		"data/b.txt":       &fstest.MapFile{Data: []byte("b"), ModTime: modTime},
+		"data/sub/c.txt":   &fstest.MapFile{Data: []byte("c"), ModTime: modTime},
+		"data/.hidden.txt": &fstest.MapFile{Data: []byte("h"), ModTime: modTime},
+		"outside/d.txt":    &fstest.MapFile{Data: []byte("d"), ModTime: modTime},
+	}
+	watcher := New()
+	if err := watcher.AddFS("archive", fsys, WatchRequest{Path: "data"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if fileCount := len(watcher.fsWatches["archive"].files); fileCount != 2 {
+		t.Errorf("only the 2 visible files directly in data should be watched, got %d", fileCount)
+	}
+
+	// change the file system
+	fsys["data/new.txt"] = &fstest.MapFile{Data: []byte("new"), ModTime: modTime}
+	fsys["data/a.txt"] = &fstest.MapFile{Data: []byte("updated"), ModTime: modTime.Add(time.Minute)}
+	delete(fsys, "data/b.txt")
+	fsys["data/sub/ignored.txt"] = &fstest.MapFile{Data: []byte("not recursive"), ModTime: modTime}
+
+	events, err := watcher.ScanNow(context.Background())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	wantEvents := map[string]FileChange{"data/new.txt": Add, "data/a.txt": Write, "data/b.txt": Remove}
+	if len(events) != len(wantEvents) {
+		t.Errorf("should have received %d events, got %d", len(wantEvents), len(events))
+	}
+	for _, event := range events {
+		if wantChange, found := wantEvents[event.FilePath]; !found || wantChange != event.FileChange {
+			t.Errorf("unexpected %s event for %s", event.FileChange, event.FilePath)
+		}
+	}
+
+	// replacing the file system reports the differences
+	replacement := fstest.MapFS{"data/a.txt": fsys["data/a.txt"], "data/new.txt": fsys["data/new.txt"]}
+	if err = watcher.UpdateFS("archive", replacement); err != nil {
+		t.Fatal(err.Error())
+	}
+	replacement["data/replaced.txt"] = &fstest.MapFile{Data: []byte("replaced"), ModTime: modTime}
+	if events, _ = watcher.ScanNow(context.Background()); len(events) != 1 || events[0].FilePath != "data/replaced.txt" {
+		t.Errorf("should have received 1 event for data/replaced.txt after UpdateFS, got %v", events)
+	}
+
+	// a removed file system is not scanned
+	if err = watcher.RemoveFS("archive", true); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err = watcher.RemoveFS("archive", true); err == nil {
+		t.Error("RemoveFS() should return an error when the file system is not watched")
+	}
+	delete(replacement, "data/a.txt")
+	if events, _ = watcher.ScanNow(context.Background()); len(events) != 0 {
+		t.Errorf("should not receive events after RemoveFS, got %d", len(events))
+	}
+}
+
+// Moves can be detected in an os.DirFS because its files come from the disk
+func TestWatcher_ScanDirFS(t *testing.T) {
+	testFilePath := createTestFiles(testSubFolder2, 1)[0]
+	movedFilePath := filepath.Join(testSubFolder2, "moved"+filepath.Base(testFilePath))
+	defer removeFiles(false, testFilePath, movedFilePath)
+
+	watcher := New()
+	if err := watcher.AddFS("subFolder2", os.DirFS(testSubFolder2), WatchRequest{}); err != nil {
+		t.Fatal(err.Error())
+	}
+	moveFile(testFilePath, movedFilePath)
+
+	events, _ := watcher.ScanNow(context.Background())
+	if len(events) != 1 {
+		t.Fatalf("should have received 1 event, got %d", len(events))
+	}
+	if events[0].FileChange != Move || events[0].FilePath != filepath.Base(movedFilePath) || events[0].PreviousPath != filepath.Base(testFilePath) {
+		t.Errorf("want Move event from %s to %s, got %s event from %s to %s", filepath.Base(testFilePath), filepath.Base(movedFilePath),
+			events[0].FileChange, events[0].PreviousPath, events[0].FilePath)
+	}
+}
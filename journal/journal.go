@@ -0,0 +1,391 @@
+// Package journal keeps a durable record of folderWatcher events in append-only files, so consumers can replay the
+// events they missed after a crash or restart.
+//
+// The journal numbers the events itself, continuing from the last event in the journal, so the numbers keep
+// increasing when the watcher is restarted. The Sequence of each stored event is the journal's number.
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// SyncPolicy determines when the journal is flushed to the disk
+type SyncPolicy int
+
+const (
+	// sync after every event, so an event is never lost once Append returns
+	SyncEveryEvent SyncPolicy = 0
+	// sync at most once per SyncInterval, so events from the last interval can be lost if the system fails
+	SyncInterval SyncPolicy = 1
+	// leave syncing to the operating system
+	SyncNever SyncPolicy = 2
+)
+
+func (sp SyncPolicy) String() string {
+	policyStrings := [...]string{"EveryEvent", "Interval", "Never"}
+	if sp < 0 || int(sp) >= len(policyStrings) {
+		return fmt.Sprintf("SyncPolicy(%d)", sp)
+	}
+	return policyStrings[sp]
+}
+
+// Defaults used for the Config fields which are not set
+const (
+	DefaultSegmentSize  = 16 * 1024 * 1024
+	DefaultSyncInterval = time.Second
+)
+
+// ErrNotAvailable is returned by ReplayFrom when events have been removed by the retention policy, so the consumer
+// cannot resume and should rescan instead
+var ErrNotAvailable = errors.New("the events have been removed from the journal")
+
+const segmentExtension = ".jsonl"
+
+type Config struct {
+	// folder containing the segment files
+	Folder string
+	// size in bytes at which a new segment file is started
+	SegmentSize int64
+	Sync        SyncPolicy
+	// longest time between syncs with the SyncInterval policy
+	SyncInterval time.Duration
+	// number of segments to keep, including the one being written. All segments are kept when it is 0.
+	MaxSegments int
+	// segments last written longer ago than this are removed. Segments are kept regardless of age when it is 0.
+	MaxAge time.Duration
+}
+
+// Journal writes events to segment files in a folder. Each segment is named after the sequence number of its first
+// event and holds one JSON encoded event per line.
+type Journal struct {
+	mutex        sync.Mutex
+	config       Config
+	segment      *os.File
+	segmentSize  int64
+	lastSequence uint64
+	unsynced     bool
+	lastSync     time.Time
+}
+
+// Open opens the journal in the folder, creating it if needed. A partly written event left by a crash is removed.
+func Open(config Config) (j *Journal, err error) {
+	if config.Folder == "" {
+		err = errors.New("a folder is required for the journal")
+		return
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = DefaultSegmentSize
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = DefaultSyncInterval
+	}
+	if err = os.MkdirAll(config.Folder, 0700); err != nil {
+		return
+	}
+
+	j = &Journal{config: config, lastSync: time.Now()}
+	segments, err := listSegments(config.Folder)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return
+	}
+
+	// continue the last segment
+	last := segments[len(segments)-1]
+	if j.lastSequence, j.segmentSize, err = recoverSegment(last.path); err != nil {
+		return nil, err
+	}
+	if j.lastSequence == 0 {
+		// the segment has no complete events, so its name gives the next number
+		j.lastSequence = last.firstSequence - 1
+	}
+	if j.segment, err = os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// segment is a journal file and the sequence number of its first event
+type segment struct {
+	path          string
+	firstSequence uint64
+}
+
+func segmentPath(folder string, firstSequence uint64) string {
+	return filepath.Join(folder, fmt.Sprintf("%020d%s", firstSequence, segmentExtension))
+}
+
+// List the segments in the folder, oldest first
+func listSegments(folder string) (segments []segment, err error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		sequenceText := strings.TrimSuffix(entry.Name(), segmentExtension)
+		firstSequence, parseErr := strconv.ParseUint(sequenceText, 10, 64)
+		if entry.IsDir() || sequenceText == entry.Name() || parseErr != nil {
+			continue
+		}
+		segments = append(segments, segment{path: filepath.Join(folder, entry.Name()), firstSequence: firstSequence})
+	}
+	sort.Slice(segments, func(i, k int) bool { return segments[i].firstSequence < segments[k].firstSequence })
+	return
+}
+
+// Find the last event in the segment, cutting off anything after the last complete line
+func recoverSegment(segmentPath string) (lastSequence uint64, size int64, err error) {
+	err = readSegment(segmentPath, func(event folderWatcher.FileEvent, end int64) error {
+		lastSequence, size = event.Sequence, end
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	err = os.Truncate(segmentPath, size)
+	return
+}
+
+// Call the function for each complete event in the segment, with the offset of the end of its line. Reading stops
+// at a line without a newline, which is still being written or was cut short by a crash.
+func readSegment(segmentPath string, eventFunc func(event folderWatcher.FileEvent, end int64) error) (err error) {
+	file, err := os.Open(segmentPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+		offset += int64(len(line))
+
+		var event folderWatcher.FileEvent
+		if err = json.Unmarshal(line, &event); err != nil {
+			return errors.New(fmt.Sprintf("cannot read the event ending at %d in %s: %s", offset, segmentPath, err.Error()))
+		}
+		if err = eventFunc(event, offset); err != nil {
+			return
+		}
+	}
+}
+
+// LastSequence returns the sequence number of the last event in the journal, or 0 if it is empty
+func (j *Journal) LastSequence() uint64 {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.lastSequence
+}
+
+// Append adds the event to the journal, numbering it after the last event, and returns its sequence number
+func (j *Journal) Append(event folderWatcher.FileEvent) (sequence uint64, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	event.Sequence = j.lastSequence + 1
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	if j.segment == nil || (j.segmentSize > 0 && j.segmentSize+int64(len(line)) > j.config.SegmentSize) {
+		if err = j.startSegment(event.Sequence); err != nil {
+			return
+		}
+	}
+	// write the whole line at once, so readers never see part of an event followed by a newline
+	if _, err = j.segment.Write(line); err != nil {
+		return
+	}
+	j.segmentSize += int64(len(line))
+	j.lastSequence = event.Sequence
+	sequence = event.Sequence
+
+	j.unsynced = true
+	switch j.config.Sync {
+	case SyncEveryEvent:
+		err = j.sync()
+	case SyncInterval:
+		if time.Since(j.lastSync) >= j.config.SyncInterval {
+			err = j.sync()
+		}
+	}
+	return
+}
+
+// Close the current segment and start a new one, then apply the retention policy
+func (j *Journal) startSegment(firstSequence uint64) (err error) {
+	if j.segment != nil {
+		if err = j.sync(); err != nil {
+			return
+		}
+		if err = j.segment.Close(); err != nil {
+			return
+		}
+		j.segment = nil
+	}
+
+	if j.segment, err = os.OpenFile(segmentPath(j.config.Folder, firstSequence), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		return
+	}
+	j.segmentSize = 0
+	return j.removeOldSegments()
+}
+
+// Remove the segments the retention policy no longer keeps. The segment being written is always kept.
+func (j *Journal) removeOldSegments() (err error) {
+	segments, err := listSegments(j.config.Folder)
+	if err != nil || len(segments) == 0 {
+		return
+	}
+	previous := segments[:len(segments)-1]
+
+	removeCount := 0
+	if j.config.MaxSegments > 0 && len(segments) > j.config.MaxSegments {
+		removeCount = len(segments) - j.config.MaxSegments
+	}
+	if j.config.MaxAge > 0 {
+		for removeCount < len(previous) {
+			info, statErr := os.Stat(previous[removeCount].path)
+			if statErr != nil || time.Since(info.ModTime()) <= j.config.MaxAge {
+				break
+			}
+			removeCount++
+		}
+	}
+
+	for _, s := range previous[:removeCount] {
+		if err = os.Remove(s.path); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (j *Journal) sync() (err error) {
+	if !j.unsynced || j.segment == nil {
+		return
+	}
+	if err = j.segment.Sync(); err == nil {
+		j.unsynced = false
+		j.lastSync = time.Now()
+	}
+	return
+}
+
+// Sync flushes the events written so far to the disk
+func (j *Journal) Sync() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.sync()
+}
+
+// Close syncs and closes the journal
+func (j *Journal) Close() (err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.segment == nil {
+		return
+	}
+	err = j.sync()
+	if closeErr := j.segment.Close(); err == nil {
+		err = closeErr
+	}
+	j.segment = nil
+	return
+}
+
+// Run appends the events from the channel, such as the Events channel of a Subscription, until the channel is
+// closed or the context is cancelled. With the SyncInterval policy, the journal is also synced when no events
+// arrive for the interval.
+func (j *Journal) Run(ctx context.Context, events <-chan folderWatcher.FileEvent) (err error) {
+	ticker := time.NewTicker(j.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return j.Sync()
+			}
+			if _, err = j.Append(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if j.config.Sync == SyncInterval {
+				if err = j.Sync(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ReplayFrom calls the function for each event in the journal with a sequence number of at least sequence, in
+// order. Replaying stops at the first error returned by the function.
+func (j *Journal) ReplayFrom(sequence uint64, eventFunc func(event folderWatcher.FileEvent) error) error {
+	return ReplayFrom(j.config.Folder, sequence, eventFunc)
+}
+
+// ReplayFrom reads the journal in the folder, so another process can replay the events while the journal is being
+// written. A sequence of 0 replays every event still in the journal. Otherwise ErrNotAvailable is returned if the
+// retention policy has removed the event with the sequence number.
+func ReplayFrom(folder string, sequence uint64, eventFunc func(event folderWatcher.FileEvent) error) (err error) {
+	segments, err := listSegments(folder)
+	if err != nil || len(segments) == 0 {
+		return
+	}
+	if sequence > 0 && sequence < segments[0].firstSequence {
+		return ErrNotAvailable
+	}
+
+	// start at the last segment beginning at or before the sequence number
+	start := 0
+	for i, s := range segments {
+		if s.firstSequence <= sequence {
+			start = i
+		}
+	}
+	for _, s := range segments[start:] {
+		err = readSegment(s.path, func(event folderWatcher.FileEvent, end int64) error {
+			if event.Sequence < sequence {
+				return nil
+			}
+			return eventFunc(event)
+		})
+		if errors.Is(err, os.ErrNotExist) {
+			// removed by the retention policy while being replayed
+			return ErrNotAvailable
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
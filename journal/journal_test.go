@@ -0,0 +1,179 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+func testEvent(i int) folderWatcher.FileEvent {
+	// the watcher's sequence numbers are replaced by the journal's
+	return folderWatcher.FileEvent{FileChange: folderWatcher.Write, FilePath: "/data/a.txt", Sequence: 1000 + uint64(i)}
+}
+
+// Collect the sequence numbers of the replayed events
+func replay(folder string, sequence uint64) (sequences []uint64, err error) {
+	err = ReplayFrom(folder, sequence, func(event folderWatcher.FileEvent) error {
+		sequences = append(sequences, event.Sequence)
+		return nil
+	})
+	return
+}
+
+func TestSyncPolicy_String(t *testing.T) {
+	if got := SyncNever.String(); got != "Never" {
+		t.Errorf("String() should return Never, got %s", got)
+	}
+	if got := SyncPolicy(3).String(); got != "SyncPolicy(3)" {
+		t.Errorf("String() should return SyncPolicy(3) for an unknown policy, got %s", got)
+	}
+}
+
+func TestJournal_ReplayFrom(t *testing.T) {
+	folder := t.TempDir()
+	// small segments, so the events are spread across several files
+	j, err := Open(Config{Folder: folder, SegmentSize: 200})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i := 1; i <= 10; i++ {
+		if sequence, appendErr := j.Append(testEvent(i)); appendErr != nil || sequence != uint64(i) {
+			t.Fatalf("Append() = %d, %v, want %d", sequence, appendErr, i)
+		}
+	}
+	if segments, _ := listSegments(folder); len(segments) < 3 {
+		t.Errorf("the events should be in several segments, got %d", len(segments))
+	}
+
+	tests := []struct {
+		name     string
+		sequence uint64
+		want     int
+	}{
+		{name: "everything", sequence: 0, want: 10},
+		{name: "from the start", sequence: 1, want: 10},
+		{name: "from the middle", sequence: 6, want: 5},
+		{name: "last event", sequence: 10, want: 1},
+		{name: "after the last event", sequence: 11, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replayErr := replay(folder, tt.sequence)
+			if replayErr != nil {
+				t.Fatal(replayErr.Error())
+			}
+			if len(got) != tt.want {
+				t.Fatalf("ReplayFrom(%d) should replay %d events, got %v", tt.sequence, tt.want, got)
+			}
+			for i, sequence := range got {
+				if wantSequence := uint64(11 - tt.want + i); sequence != wantSequence {
+					t.Errorf("event %d should have Sequence %d, got %d", i, wantSequence, sequence)
+				}
+			}
+		})
+	}
+
+	// replaying stops at the first error
+	stop := errors.New("stop")
+	count := 0
+	if err = j.ReplayFrom(1, func(event folderWatcher.FileEvent) error { count++; return stop }); err != stop || count != 1 {
+		t.Errorf("ReplayFrom() should stop at the first error, got %v after %d events", err, count)
+	}
+	_ = j.Close()
+}
+
+// A reopened journal continues the numbering, after removing a partly written event
+func TestOpen_Recover(t *testing.T) {
+	folder := t.TempDir()
+	j, _ := Open(Config{Folder: folder})
+	for i := 1; i <= 3; i++ {
+		_, _ = j.Append(testEvent(i))
+	}
+	_ = j.Close()
+
+	segments, _ := listSegments(folder)
+	file, _ := os.OpenFile(segments[0].path, os.O_WRONLY|os.O_APPEND, 0600)
+	_, _ = file.WriteString(`{"version":1,"kind":"Wri`)
+	_ = file.Close()
+
+	j, err := Open(Config{Folder: folder})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer j.Close()
+	if j.LastSequence() != 3 {
+		t.Errorf("LastSequence() should be 3, got %d", j.LastSequence())
+	}
+	if sequence, _ := j.Append(testEvent(4)); sequence != 4 {
+		t.Errorf("Append() should continue from 3, got %d", sequence)
+	}
+	if got, err := replay(folder, 0); err != nil || len(got) != 4 {
+		t.Errorf("ReplayFrom() should replay 4 events, got %v, %v", got, err)
+	}
+}
+
+func TestJournal_Retention(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		ageSegments  bool
+		wantSegments int
+	}{
+		{name: "keep everything", config: Config{}, wantSegments: 5},
+		{name: "max segments", config: Config{MaxSegments: 2}, wantSegments: 2},
+		{name: "max age", config: Config{MaxAge: time.Hour}, ageSegments: true, wantSegments: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := t.TempDir()
+			config := tt.config
+			config.Folder = folder
+			// a new segment for every event
+			config.SegmentSize = 1
+			j, _ := Open(config)
+			defer j.Close()
+			for i := 1; i <= 5; i++ {
+				if tt.ageSegments && i == 5 {
+					segments, _ := listSegments(folder)
+					for _, s := range segments {
+						_ = os.Chtimes(s.path, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))
+					}
+				}
+				_, _ = j.Append(testEvent(i))
+			}
+
+			segments, _ := listSegments(folder)
+			if len(segments) != tt.wantSegments {
+				t.Fatalf("should have %d segments, got %d", tt.wantSegments, len(segments))
+			}
+			if got, err := replay(folder, 0); err != nil || len(got) != tt.wantSegments {
+				t.Errorf("ReplayFrom(0) should replay the %d remaining events, got %v, %v", tt.wantSegments, got, err)
+			}
+			if _, err := replay(folder, 1); tt.wantSegments < 5 && err != ErrNotAvailable {
+				t.Errorf("ReplayFrom(1) should return ErrNotAvailable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJournal_Run(t *testing.T) {
+	folder := t.TempDir()
+	j, _ := Open(Config{Folder: folder, Sync: SyncInterval})
+	defer j.Close()
+
+	events := make(chan folderWatcher.FileEvent, 3)
+	for i := 1; i <= 3; i++ {
+		events <- testEvent(i)
+	}
+	close(events)
+	if err := j.Run(context.Background(), events); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got, err := replay(folder, 0); err != nil || len(got) != 3 {
+		t.Errorf("ReplayFrom() should replay 3 events, got %v, %v", got, err)
+	}
+}
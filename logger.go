@@ -0,0 +1,21 @@
+package folderWatcher
+
+// Logger receives the watcher's diagnostic records. Each record is a message followed by alternating keys and
+// values, in the style of log/slog, so a *slog.Logger can be used directly.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// NopLogger discards every record. This is the default Logger for a new Watcher.
+type NopLogger struct{}
+
+func (NopLogger) Debug(msg string, keyvals ...interface{}) {}
+
+func (NopLogger) Info(msg string, keyvals ...interface{}) {}
+
+func (NopLogger) Warn(msg string, keyvals ...interface{}) {}
+
+func (NopLogger) Error(msg string, keyvals ...interface{}) {}
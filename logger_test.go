@@ -0,0 +1,92 @@
+package folderWatcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+type logRecord struct {
+	level   string
+	msg     string
+	keyvals []interface{}
+}
+
+// recordingLogger keeps every record it receives
+type recordingLogger struct {
+	records []logRecord
+}
+
+func (rl *recordingLogger) record(level string, msg string, keyvals []interface{}) {
+	rl.records = append(rl.records, logRecord{level: level, msg: msg, keyvals: keyvals})
+}
+
+func (rl *recordingLogger) Debug(msg string, keyvals ...interface{}) {
+	rl.record("debug", msg, keyvals)
+}
+
+func (rl *recordingLogger) Info(msg string, keyvals ...interface{}) { rl.record("info", msg, keyvals) }
+
+func (rl *recordingLogger) Warn(msg string, keyvals ...interface{}) { rl.record("warn", msg, keyvals) }
+
+func (rl *recordingLogger) Error(msg string, keyvals ...interface{}) {
+	rl.record("error", msg, keyvals)
+}
+
+// Find the first record with the message and return the value of a key
+func (rl *recordingLogger) find(msg string, key string) (level string, value interface{}, found bool) {
+	for _, record := range rl.records {
+		if record.msg != msg {
+			continue
+		}
+		for i := 0; i+1 < len(record.keyvals); i += 2 {
+			if record.keyvals[i] == key {
+				return record.level, record.keyvals[i+1], true
+			}
+		}
+	}
+	return
+}
+
+func TestWatcher_Logger(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.Mkdir("/missing")
+	logger := &recordingLogger{}
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Logger = logger
+	watcher.IntervalOverride = 2000
+	_ = watcher.AddFolder("/data", true, false)
+	_ = watcher.AddFolder("/missing", false, false)
+	_ = fsys.Remove("/missing")
+	_, _ = watcher.ScanNow(context.Background())
+	_ = watcher.RemoveFolder("/data", false)
+
+	tests := []struct {
+		msg       string
+		key       string
+		wantLevel string
+		wantValue interface{}
+	}{
+		{msg: "watch added", key: "path", wantLevel: "debug", wantValue: AbsPath("/data")},
+		{msg: "scan started", key: "cycle", wantLevel: "debug", wantValue: uint64(1)},
+		{msg: "cannot read watched folder", key: "path", wantLevel: "warn", wantValue: AbsPath("/missing")},
+		{msg: "interval changed", key: "interval", wantLevel: "debug", wantValue: 2000},
+		{msg: "scan finished", key: "files", wantLevel: "debug", wantValue: 1},
+		{msg: "watch removed", key: "path", wantLevel: "debug", wantValue: AbsPath("/data")},
+	}
+	for _, tt := range tests {
+		level, value, found := logger.find(tt.msg, tt.key)
+		if !found || level != tt.wantLevel || value != tt.wantValue {
+			t.Errorf("want %s record \"%s\" with %s=%v, got found=%v level=%s value=%v", tt.wantLevel, tt.msg, tt.key,
+				tt.wantValue, found, level, value)
+		}
+	}
+	for _, record := range logger.records {
+		if len(record.keyvals)%2 != 0 {
+			t.Errorf("record \"%s\" should have pairs of keys and values, got %v", record.msg, record.keyvals)
+		}
+	}
+}
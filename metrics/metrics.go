@@ -0,0 +1,167 @@
+// Package metrics collects metrics for a folderWatcher.Watcher and serves them in the Prometheus text exposition
+// format, without depending on a Prometheus client library.
+//
+//	m := metrics.New()
+//	watcher.Observer = m
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// Upper bounds of the histogram buckets, in seconds
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram counts durations in cumulative buckets
+type histogram struct {
+	buckets      []float64
+	bucketCounts []uint64
+	sum          time.Duration
+	count        uint64
+}
+
+func newHistogram(buckets []float64) histogram {
+	return histogram{buckets: buckets, bucketCounts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(duration time.Duration) {
+	for i, bound := range h.buckets {
+		if duration.Seconds() <= bound {
+			h.bucketCounts[i]++
+		}
+	}
+	h.sum += duration
+	h.count++
+}
+
+// Metrics is a folderWatcher.Observer which records the watcher's activity, and an http.Handler which serves it
+type Metrics struct {
+	mutex           sync.Mutex
+	scanDuration    histogram
+	deliveryLatency histogram
+	filesScanned    int
+	foldersScanned  int
+	scanErrors      uint64
+	eventCounts     map[folderWatcher.FileChange]uint64
+	interval        int
+	queueDepth      int
+}
+
+func New() *Metrics {
+	return &Metrics{
+		scanDuration:    newHistogram(DefaultBuckets),
+		deliveryLatency: newHistogram(DefaultBuckets),
+		eventCounts:     make(map[folderWatcher.FileChange]uint64),
+	}
+}
+
+func (m *Metrics) ScanStarted(cycle uint64) {}
+
+// ScanFinished records a completed scan. Cancelled scans are ignored because their counts are incomplete.
+func (m *Metrics) ScanFinished(stats folderWatcher.ScanStats) {
+	if stats.Err != nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.scanDuration.observe(stats.Duration)
+	m.filesScanned = stats.FilesScanned
+	m.foldersScanned = stats.FoldersScanned
+	m.scanErrors += uint64(stats.Errors)
+	m.interval = stats.Interval
+	m.queueDepth = stats.QueueDepth
+}
+
+func (m *Metrics) EventEmitted(event folderWatcher.FileEvent) {
+	m.mutex.Lock()
+	m.eventCounts[event.FileChange]++
+	m.mutex.Unlock()
+}
+
+func (m *Metrics) EventDelivered(event folderWatcher.FileEvent, latency time.Duration) {
+	m.mutex.Lock()
+	m.deliveryLatency.observe(latency)
+	m.mutex.Unlock()
+}
+
+func (m *Metrics) WatchAdded(root string) {}
+
+func (m *Metrics) WatchRemoved(root string) {}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// WriteTo writes the metrics in the text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (n int64, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var written int
+	write := func(format string, args ...interface{}) {
+		if err != nil {
+			return
+		}
+		written, err = fmt.Fprintf(w, format, args...)
+		n += int64(written)
+	}
+
+	writeHistogram := func(name string, help string, h *histogram) {
+		write("# HELP %s %s\n", name, help)
+		write("# TYPE %s histogram\n", name)
+		for i, bound := range h.buckets {
+			write("%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), h.bucketCounts[i])
+		}
+		write("%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+		write("%s_sum %s\n", name, formatFloat(h.sum.Seconds()))
+		write("%s_count %d\n", name, h.count)
+	}
+
+	writeHistogram("folderwatcher_scan_duration_seconds", "Time taken to scan the watched files.", &m.scanDuration)
+	write("# HELP folderwatcher_files_scanned Number of files read by the last scan.\n")
+	write("# TYPE folderwatcher_files_scanned gauge\n")
+	write("folderwatcher_files_scanned %d\n", m.filesScanned)
+	write("# HELP folderwatcher_folders_scanned Number of folders read by the last scan.\n")
+	write("# TYPE folderwatcher_folders_scanned gauge\n")
+	write("folderwatcher_folders_scanned %d\n", m.foldersScanned)
+
+	write("# HELP folderwatcher_events_total Number of events sent, by kind.\n")
+	write("# TYPE folderwatcher_events_total counter\n")
+	kinds := make([]folderWatcher.FileChange, 0, len(m.eventCounts))
+	for kind := range m.eventCounts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, k int) bool { return kinds[i] < kinds[k] })
+	for _, kind := range kinds {
+		write("folderwatcher_events_total{kind=\"%s\"} %d\n", kind, m.eventCounts[kind])
+	}
+
+	write("# HELP folderwatcher_scan_errors_total Number of watched folders which could not be read.\n")
+	write("# TYPE folderwatcher_scan_errors_total counter\n")
+	write("folderwatcher_scan_errors_total %d\n", m.scanErrors)
+	write("# HELP folderwatcher_interval_seconds Time between scans.\n")
+	write("# TYPE folderwatcher_interval_seconds gauge\n")
+	write("folderwatcher_interval_seconds %s\n", formatFloat(float64(m.interval)/1000))
+	write("# HELP folderwatcher_queue_depth Number of events waiting for delivery.\n")
+	write("# TYPE folderwatcher_queue_depth gauge\n")
+	write("folderwatcher_queue_depth %d\n", m.queueDepth)
+	writeHistogram("folderwatcher_delivery_latency_seconds", "Time from detecting a change to delivering the event.",
+		&m.deliveryLatency)
+	return
+}
+
+// ServeHTTP serves the metrics in the text exposition format
+func (m *Metrics) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(writer)
+}
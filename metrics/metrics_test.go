@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+var _ folderWatcher.Observer = (*Metrics)(nil)
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	m := New()
+	watcher := folderWatcher.New()
+	watcher.FileSystem = fsys
+	watcher.Observer = m
+	watcher.IntervalOverride = 2500
+	_ = watcher.AddFolder("/data", true, false)
+
+	fsys.WriteFile("/data/b.txt", []byte("b"))
+	fsys.WriteFile("/data/c.txt", []byte("c"))
+	_, _ = watcher.ScanNow(context.Background())
+	_ = fsys.Remove("/data/a.txt")
+	_, _ = watcher.ScanNow(context.Background())
+	// cancelled scans are not counted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = watcher.ScanNow(ctx)
+	m.EventDelivered(folderWatcher.FileEvent{}, 30*time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type should be the text exposition format, got %s", contentType)
+	}
+
+	body := recorder.Body.String()
+	for _, wantLine := range []string{
+		"# TYPE folderwatcher_scan_duration_seconds histogram",
+		`folderwatcher_scan_duration_seconds_bucket{le="+Inf"} 2`,
+		"folderwatcher_scan_duration_seconds_count 2",
+		"folderwatcher_files_scanned 2",
+		"folderwatcher_folders_scanned 1",
+		`folderwatcher_events_total{kind="Add"} 2`,
+		`folderwatcher_events_total{kind="Remove"} 1`,
+		"folderwatcher_scan_errors_total 0",
+		"folderwatcher_interval_seconds 2.5",
+		"folderwatcher_queue_depth 0",
+		`folderwatcher_delivery_latency_seconds_bucket{le="0.025"} 0`,
+		`folderwatcher_delivery_latency_seconds_bucket{le="0.05"} 1`,
+		"folderwatcher_delivery_latency_seconds_count 1",
+	} {
+		if !strings.Contains(body, wantLine+"\n") {
+			t.Errorf("the metrics should contain %s, got\n%s", wantLine, body)
+		}
+	}
+}
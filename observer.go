@@ -0,0 +1,55 @@
+package folderWatcher
+
+import "time"
+
+// ScanStats describes a completed scan
+type ScanStats struct {
+	Cycle    uint64
+	Duration time.Duration
+	// number of files and folders read, including those of file systems added with AddFS
+	FilesScanned   int
+	FoldersScanned int
+	// number of changes found, before suppressed and expected changes are removed
+	Events int
+	// number of watched folders and file systems which could not be read
+	Errors int
+	// the interval until the next scan, in milliseconds
+	Interval int
+	// number of events waiting for delivery when the scan finished
+	QueueDepth int
+	// the context's error if the scan was cancelled, in which case the counts are incomplete and no events were found
+	Err error
+}
+
+// Observer is notified of the watcher's activity, for example to collect metrics or trace scans. The scan and
+// watch methods are called while the scan lock is held, and EventDelivered is called by the delivery goroutine, so
+// the methods should return quickly and be safe to call from several goroutines. Embed NopObserver to implement
+// only some of the methods.
+type Observer interface {
+	ScanStarted(cycle uint64)
+	// called for every scan which was started, including cancelled scans
+	ScanFinished(stats ScanStats)
+	// called for each event sent to the consumers
+	EventEmitted(event FileEvent)
+	// called once the event has been passed to the subscribers and the FileChanged channel. The latency is the time
+	// since the event was detected, according to the watcher's Clock.
+	EventDelivered(event FileEvent, latency time.Duration)
+	// called when a watch is added or removed. The root is the value used for the Root of the watch's events.
+	WatchAdded(root string)
+	WatchRemoved(root string)
+}
+
+// NopObserver ignores everything. This is the default Observer for a new Watcher.
+type NopObserver struct{}
+
+func (NopObserver) ScanStarted(cycle uint64) {}
+
+func (NopObserver) ScanFinished(stats ScanStats) {}
+
+func (NopObserver) EventEmitted(event FileEvent) {}
+
+func (NopObserver) EventDelivered(event FileEvent, latency time.Duration) {}
+
+func (NopObserver) WatchAdded(root string) {}
+
+func (NopObserver) WatchRemoved(root string) {}
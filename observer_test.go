@@ -0,0 +1,122 @@
+package folderWatcher
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+// recordingObserver keeps everything it is told
+type recordingObserver struct {
+	mutex     sync.Mutex
+	started   []uint64
+	finished  []ScanStats
+	emitted   []FileEvent
+	delivered []FileEvent
+	latencies []time.Duration
+	added     []string
+	removed   []string
+}
+
+func (ro *recordingObserver) ScanStarted(cycle uint64) { ro.started = append(ro.started, cycle) }
+
+func (ro *recordingObserver) ScanFinished(stats ScanStats) { ro.finished = append(ro.finished, stats) }
+
+func (ro *recordingObserver) EventEmitted(event FileEvent) { ro.emitted = append(ro.emitted, event) }
+
+func (ro *recordingObserver) EventDelivered(event FileEvent, latency time.Duration) {
+	ro.mutex.Lock()
+	defer ro.mutex.Unlock()
+	ro.delivered = append(ro.delivered, event)
+	ro.latencies = append(ro.latencies, latency)
+}
+
+func (ro *recordingObserver) WatchAdded(root string) { ro.added = append(ro.added, root) }
+
+func (ro *recordingObserver) WatchRemoved(root string) { ro.removed = append(ro.removed, root) }
+
+func TestWatcher_Observer(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	fsys.Mkdir("/missing")
+	observer := &recordingObserver{}
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Observer = observer
+	_ = watcher.AddFolder("/data", true, false)
+	_ = watcher.AddFolder("/missing", false, false)
+	_ = watcher.AddFS("archive", fstest.MapFS{"c.txt": &fstest.MapFile{Data: []byte("c")}}, WatchRequest{})
+	_ = fsys.Remove("/missing")
+
+	fsys.WriteFile("/data/d.txt", []byte("d"))
+	fsys.WriteFile("/data/e.txt", []byte("e"))
+	watcher.Suppress("/data/e.txt")
+	_, _ = watcher.ScanNow(context.Background())
+
+	if len(observer.started) != 1 || observer.started[0] != 1 || len(observer.finished) != 1 {
+		t.Fatalf("want 1 started and finished scan, got %v and %v", observer.started, observer.finished)
+	}
+	stats := observer.finished[0]
+	want := ScanStats{Cycle: 1, FilesScanned: 5, FoldersScanned: 3, Events: 2, Errors: 1, Interval: watcher.Interval}
+	stats.Duration = 0
+	if stats != want {
+		t.Errorf("ScanFinished() stats = %+v, want %+v", stats, want)
+	}
+	if len(observer.emitted) != 1 || observer.emitted[0].FilePath != AbsPath("/data/d.txt") {
+		t.Errorf("only the event for /data/d.txt should be emitted, got %v", observer.emitted)
+	}
+
+	// a cancelled scan finishes with the context's error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = watcher.ScanNow(ctx)
+	if len(observer.started) != 2 || len(observer.finished) != 2 || observer.finished[1].Err != context.Canceled {
+		t.Errorf("a cancelled scan should finish with context.Canceled, got %d started and %v finished", len(observer.started), observer.finished)
+	}
+
+	_ = watcher.RemoveFS("archive", false)
+	_ = watcher.RemoveFolder("/missing", false)
+	wantAdded, wantRemoved := []string{AbsPath("/data"), AbsPath("/missing"), "archive"}, []string{"archive", AbsPath("/missing")}
+	if !reflect.DeepEqual(observer.added, wantAdded) || !reflect.DeepEqual(observer.removed, wantRemoved) {
+		t.Errorf("want watches %v added and %v removed, got %v and %v", wantAdded, wantRemoved, observer.added, observer.removed)
+	}
+}
+
+func TestWatcher_ObserverEventDelivered(t *testing.T) {
+	clock := folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	fsys := folderwatchertest.NewFS()
+	fsys.Mkdir("/data")
+	observer := &recordingObserver{}
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Clock = clock
+	watcher.Observer = observer
+	watcher.FileChanged = nil
+	_ = watcher.AddFolder("/data", false, false)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	defer watcher.Stop()
+
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/b.txt", []byte("b"))
+	events, _ := watcher.ScanNow(context.Background())
+	watcher.Flush()
+
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+	if len(observer.delivered) != len(events) || len(events) != 2 {
+		t.Fatalf("EventDelivered() should be called for the 2 events, got %d", len(observer.delivered))
+	}
+	for i, event := range observer.delivered {
+		if event.Sequence != events[i].Sequence || observer.latencies[i] != 0 {
+			t.Errorf("want event %d delivered with no latency as the clock was not advanced, got %d after %s", events[i].Sequence,
+				event.Sequence, observer.latencies[i])
+		}
+	}
+}
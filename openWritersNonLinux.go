@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package folderWatcher
+
+// Only Linux reports which files processes have open, so no files are found to be open for writing
+func findOpenWriters(filePaths []string) (openPaths map[string]bool) {
+	return make(map[string]bool)
+}
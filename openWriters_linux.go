@@ -0,0 +1,55 @@
+package folderWatcher
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Find which of the files are open for writing by any process, by reading the file descriptors in /proc. Processes
+// owned by other users cannot be read without privileges, so their files are not found.
+func findOpenWriters(filePaths []string) (openPaths map[string]bool) {
+	openPaths = make(map[string]bool)
+	if len(filePaths) == 0 {
+		return
+	}
+	isWanted := make(map[string]bool)
+	for _, filePath := range filePaths {
+		isWanted[filePath] = true
+	}
+
+	fdPaths, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fdPath := range fdPaths {
+		target, err := os.Readlink(fdPath)
+		if err != nil || !isWanted[target] || openPaths[target] {
+			continue
+		}
+		// /proc/<pid>/fdinfo/<fd> has the flags the file was opened with
+		fdInfoPath := filepath.Join(filepath.Dir(filepath.Dir(fdPath)), "fdinfo", filepath.Base(fdPath))
+		if flags, found := readFDFlags(fdInfoPath); found && flags&(syscall.O_WRONLY|syscall.O_RDWR) != 0 {
+			openPaths[target] = true
+		}
+	}
+	return
+}
+
+// Read the octal flags line of a file descriptor's fdinfo
+func readFDFlags(fdInfoPath string) (flags int64, found bool) {
+	file, err := os.Open(fdInfoPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value := strings.TrimPrefix(scanner.Text(), "flags:"); value != scanner.Text() {
+			flags, err = strconv.ParseInt(strings.TrimSpace(value), 8, 64)
+			return flags, err == nil
+		}
+	}
+	return
+}
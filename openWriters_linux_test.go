@@ -0,0 +1,50 @@
+package folderWatcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindOpenWriters(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "a.txt")
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if openPaths := findOpenWriters([]string{filePath}); !openPaths[filePath] {
+		t.Error("a file open for writing should be found")
+	}
+	_ = file.Close()
+
+	file, _ = os.Open(filePath)
+	defer file.Close()
+	if openPaths := findOpenWriters([]string{filePath}); openPaths[filePath] {
+		t.Error("a file open for reading should not be found")
+	}
+}
+
+func TestWatcher_SettleCheckOpenWriters(t *testing.T) {
+	folderPath := t.TempDir()
+	watcher := New()
+	watcher.SettleScans = 1
+	watcher.SettleCheckOpenWriters = true
+	_ = watcher.AddFolder(folderPath, false, false)
+
+	file, err := os.Create(filepath.Join(folderPath, "a.txt"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer file.Close()
+	for i := 0; i < 3; i++ {
+		if events, _ := watcher.ScanNow(context.Background()); len(events) != 0 {
+			t.Fatalf("a file open for writing should not be reported, got %v", events)
+		}
+	}
+
+	_ = file.Close()
+	if events, _ := watcher.ScanNow(context.Background()); len(events) != 1 || events[0].FileChange != Add {
+		t.Errorf("want an Add event once the file is closed, got %v", events)
+	}
+}
@@ -0,0 +1,122 @@
+package folderWatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ResumeMode determines what happens to the changes made while the watcher was paused
+type ResumeMode int
+
+const (
+	// report every change made while the watcher was paused
+	ResumeReport ResumeMode = 0
+	// accept the current files as the new baseline without sending events
+	ResumeRebaseline ResumeMode = 1
+)
+
+func (rm ResumeMode) String() string {
+	modeStrings := [...]string{"Report", "Rebaseline"}
+	if rm < 0 || int(rm) >= len(modeStrings) {
+		return fmt.Sprintf("ResumeMode(%d)", rm)
+	}
+	return modeStrings[rm]
+}
+
+// Pause temporarily stops scanning for changes. The watched files are kept as they were, so changes made while
+// paused can be reported when the watcher is resumed. Pause has no effect unless the watcher is running.
+func (w *Watcher) Pause() {
+	w.stateMutex.Lock()
+	defer w.stateMutex.Unlock()
+	if w.State == Running {
+		w.State = Paused
+	}
+}
+
+// Resume continues scanning after Pause. The mode determines if changes made while paused are reported or
+// silently accepted. Resume has no effect unless the watcher is paused.
+func (w *Watcher) Resume(mode ResumeMode) {
+	if w.getState() != Paused {
+		return
+	}
+
+	// the state is not locked during the scan, because observers may call Status while it holds the scanMutex
+	if mode == ResumeRebaseline {
+		w.rebaseline()
+	}
+
+	// the watcher may have been stopped during the scan
+	w.stateMutex.Lock()
+	defer w.stateMutex.Unlock()
+	if w.State == Paused {
+		w.State = Running
+	}
+}
+
+// Replace the watched files with the current files, without sending any events
+func (w *Watcher) rebaseline() {
+	w.scanMutex.Lock()
+	defer w.scanMutex.Unlock()
+
+	// scan as normal, but throw away the events
+	_, _ = w.scanForFileEvents(context.Background())
+}
+
+// Suppress stops events from being sent for the paths, for example while this process is writing to them. A
+// folder path suppresses everything below it. The watched files are still updated, so no events are sent for
+// the changes later.
+func (w *Watcher) Suppress(paths ...string) {
+	for _, p := range paths {
+		w.suppressedPaths.add(AbsPath(p))
+	}
+}
+
+// Unsuppress allows events to be sent again for paths passed to Suppress
+func (w *Watcher) Unsuppress(paths ...string) {
+	for _, p := range paths {
+		w.suppressedPaths.remove(AbsPath(p))
+	}
+}
+
+// pathSet is a set of paths which can be used from several goroutines
+type pathSet struct {
+	mutex sync.RWMutex
+	paths map[string]bool
+}
+
+func newPathSet() *pathSet {
+	return &pathSet{paths: make(map[string]bool)}
+}
+
+func (ps *pathSet) add(p string) {
+	ps.mutex.Lock()
+	ps.paths[p] = true
+	ps.mutex.Unlock()
+}
+
+func (ps *pathSet) remove(p string) {
+	ps.mutex.Lock()
+	delete(ps.paths, p)
+	ps.mutex.Unlock()
+}
+
+// Check if the path, or one of the folders containing it, is in the set
+func (ps *pathSet) contains(p string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	for setPath := range ps.paths {
+		if p == setPath || isWithinFolder(p, setPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if either path of the event is in the set
+func (ps *pathSet) containsEvent(event FileEvent) bool {
+	if event.FilePath != "" && ps.contains(event.FilePath) {
+		return true
+	}
+	return event.PreviousPath != "" && ps.contains(event.PreviousPath)
+}
@@ -0,0 +1,156 @@
+package folderWatcher
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+// start a watcher on testSubFolder2 with a fake clock, so it only scans when told to, and count the events received
+func startCountingWatcher(t *testing.T) (watcher *Watcher, clock *folderwatchertest.Clock, receivedEvents map[FileChange]int, stopWatcher func()) {
+	newWatcher := New()
+	watcher = &newWatcher
+	if err := watcher.AddFolder(testSubFolder2, false, false); err != nil {
+		t.Fatal(err.Error())
+	}
+	clock = folderwatchertest.NewClock(time.Now())
+	watcher.Clock = clock
+	watcher.FileChanged = nil
+	sub, _ := watcher.Subscribe(Filter{}, 0, Block)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+
+	receivedEvents = make(map[FileChange]int)
+	stopWatcher = func() {
+		watcher.Flush()
+		watcher.Stop()
+		sub.Unsubscribe()
+		for event := range sub.Events() {
+			receivedEvents[event.FileChange]++
+		}
+	}
+	return
+}
+
+// Check if the file is in the watched files
+func isWatchedFile(watcher *Watcher, filePath string) bool {
+	watcher.watchedFileMutex.RLock()
+	defer watcher.watchedFileMutex.RUnlock()
+	_, found := watcher.watchedFiles[AbsPath(filePath)]
+	return found
+}
+
+func TestResumeMode_String(t *testing.T) {
+	if got := ResumeRebaseline.String(); got != "Rebaseline" {
+		t.Errorf("String() should return Rebaseline, got %s", got)
+	}
+	if got := ResumeMode(-1).String(); got != "ResumeMode(-1)" {
+		t.Errorf("String() should return ResumeMode(-1) for an unknown mode, got %s", got)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ResumeMode
+		wantAdds int
+	}{
+		{name: "report changes made while paused", mode: ResumeReport, wantAdds: 1},
+		{name: "rebaseline", mode: ResumeRebaseline, wantAdds: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher, clock, receivedEvents, stopWatcher := startCountingWatcher(t)
+
+			watcher.Pause()
+			if state := watcher.getState(); state != Paused {
+				t.Errorf("Watcher should be in the Paused state. State=%s", state)
+			}
+			watcher.Start() // starting a paused watcher should not start another service loop
+			testFiles := createTestFiles(testSubFolder2, 1)
+			defer removeFiles(false, testFiles...)
+
+			// let the service loop run a cycle, which should not scan while paused
+			clock.BlockUntil(1)
+			clock.Advance(MaximumIntervalTime * time.Millisecond)
+			clock.BlockUntil(1)
+			if isWatchedFile(watcher, testFiles[0]) {
+				t.Errorf("%s should not be found while paused", testFiles[0])
+			}
+
+			watcher.Resume(tt.mode)
+			if state := watcher.getState(); state != Running {
+				t.Errorf("Watcher should be in the Running state after Resume. State=%s", state)
+			}
+			_, _ = watcher.ScanNow(context.Background())
+			stopWatcher()
+
+			if receivedEvents[Add] != tt.wantAdds {
+				t.Errorf("should have received %d Add events, got %d", tt.wantAdds, receivedEvents[Add])
+			}
+			if !isWatchedFile(watcher, testFiles[0]) {
+				t.Errorf("%s should be in the watched files after Resume", testFiles[0])
+			}
+		})
+	}
+}
+
+// stopObserver stops the watcher when a scan starts
+type stopObserver struct {
+	NopObserver
+	watcher *Watcher
+}
+
+func (so stopObserver) ScanStarted(cycle uint64) {
+	so.watcher.Stop()
+}
+
+// A watcher stopped while Resume rebaselines stays stopped
+func TestResume_Stopped(t *testing.T) {
+	watcher := New()
+	watcher.FileSystem = folderwatchertest.NewFS()
+	watcher.Clock = folderwatchertest.NewClock(time.Now())
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	watcher.Pause()
+
+	watcher.Observer = stopObserver{watcher: &watcher}
+	watcher.Resume(ResumeRebaseline)
+	if state := watcher.getState(); state != Stopped {
+		t.Errorf("Watcher should still be in the Stopped state after Resume. State=%s", state)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	watcher, _, receivedEvents, stopWatcher := startCountingWatcher(t)
+
+	suppressedFiles := createTestFiles(testSubFolder2, 1)
+	watcher.Suppress(suppressedFiles...)
+	_, _ = watcher.ScanNow(context.Background())
+
+	// events for other paths are still sent
+	otherFiles := createTestFiles(testSubFolder2, 1)
+	defer removeFiles(false, append(suppressedFiles, otherFiles...)...)
+	_, _ = watcher.ScanNow(context.Background())
+
+	// once unsuppressed, changes are reported again. The modification time is set, as the file may be written
+	// within the file system's timestamp resolution.
+	watcher.Unsuppress(suppressedFiles...)
+	writeToFile(suppressedFiles[0], "updated")
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(suppressedFiles[0], modTime, modTime); err != nil {
+		t.Fatal(err.Error())
+	}
+	_, _ = watcher.ScanNow(context.Background())
+	stopWatcher()
+
+	if receivedEvents[Add] != 1 {
+		t.Errorf("should have received 1 Add event, got %d", receivedEvents[Add])
+	}
+	if receivedEvents[Write] != 1 {
+		t.Errorf("should have received 1 Write event, got %d", receivedEvents[Write])
+	}
+}
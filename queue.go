@@ -0,0 +1,108 @@
+package folderWatcher
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default number of events which can wait for delivery before the QueuePolicy is applied
+const DefaultQueueSize = 1000
+
+// eventQueue sits between the scanner and the consumers so a slow consumer does not hold up scanning
+type eventQueue struct {
+	events  chan FileEvent
+	policy  OverflowPolicy
+	dropped int64
+	// number of events queued or being delivered
+	pending     int
+	pendingCond *sync.Cond
+}
+
+func newEventQueue(size int, policy OverflowPolicy) *eventQueue {
+	if size < 1 {
+		size = DefaultQueueSize
+	}
+	return &eventQueue{events: make(chan FileEvent, size), policy: policy, pendingCond: sync.NewCond(&sync.Mutex{})}
+}
+
+// Add an event to the queue. When the queue is full the event, or the oldest queued event, may be dropped
+// depending on the policy.
+func (q *eventQueue) push(event FileEvent) {
+	q.addPending(1)
+	switch q.policy {
+	case DropOldest:
+		select {
+		case q.events <- event:
+		default:
+			select {
+			case <-q.events:
+				q.drop()
+			default:
+			}
+			select {
+			case q.events <- event:
+			default:
+				q.drop()
+			}
+		}
+	case DropNewest, Disconnect:
+		// there is nobody to disconnect from the queue, so Disconnect behaves like DropNewest
+		select {
+		case q.events <- event:
+		default:
+			q.drop()
+		}
+	default:
+		q.events <- event
+	}
+}
+
+// Record an event which was removed from, or never added to, the queue
+func (q *eventQueue) drop() {
+	atomic.AddInt64(&q.dropped, 1)
+	q.done(1)
+}
+
+func (q *eventQueue) addPending(count int) {
+	q.pendingCond.L.Lock()
+	q.pending += count
+	q.pendingCond.L.Unlock()
+}
+
+// Mark events as delivered or dropped
+func (q *eventQueue) done(count int) {
+	q.pendingCond.L.Lock()
+	q.pending -= count
+	if q.pending <= 0 {
+		q.pendingCond.Broadcast()
+	}
+	q.pendingCond.L.Unlock()
+}
+
+// Wait until every queued event has been delivered or dropped
+func (q *eventQueue) wait() {
+	q.pendingCond.L.Lock()
+	for q.pending > 0 {
+		q.pendingCond.Wait()
+	}
+	q.pendingCond.L.Unlock()
+}
+
+// Return the number of events dropped since the last call and reset the count
+func (q *eventQueue) takeDropped() int64 {
+	return atomic.SwapInt64(&q.dropped, 0)
+}
+
+// Number of events waiting for delivery
+func (q *eventQueue) depth() int {
+	return len(q.events)
+}
+
+// Create the event which tells consumers that events were dropped. It is not detected by a scan, so it has no
+// Cycle or Sequence.
+func overflowEvent(droppedCount int64, detectedAt time.Time) FileEvent {
+	return FileEvent{FileChange: Overflow, DetectedAt: detectedAt,
+		Description: fmt.Sprintf("event queue overflowed, %d events were dropped and a rescan is needed", droppedCount)}
+}
@@ -0,0 +1,61 @@
+package folderWatcher
+
+import "testing"
+
+func TestEventQueue_Push(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      OverflowPolicy
+		wantDropped int64
+		wantFirst   string
+	}{
+		{name: "drop newest", policy: DropNewest, wantDropped: 1, wantFirst: "1.txt"},
+		{name: "drop oldest", policy: DropOldest, wantDropped: 1, wantFirst: "2.txt"},
+		{name: "disconnect behaves like drop newest", policy: Disconnect, wantDropped: 1, wantFirst: "1.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newEventQueue(2, tt.policy)
+			for _, filePath := range []string{"1.txt", "2.txt", "3.txt"} {
+				q.push(FileEvent{FileChange: Add, FilePath: filePath})
+			}
+
+			if q.depth() != 2 {
+				t.Errorf("queue depth should be 2, got %d", q.depth())
+			}
+			if dropped := q.takeDropped(); dropped != tt.wantDropped {
+				t.Errorf("takeDropped() = %d, want %d", dropped, tt.wantDropped)
+			}
+			if dropped := q.takeDropped(); dropped != 0 {
+				t.Errorf("takeDropped() should reset the count, got %d", dropped)
+			}
+			if first := <-q.events; first.FilePath != tt.wantFirst {
+				t.Errorf("first queued event should be %s, got %s", tt.wantFirst, first.FilePath)
+			}
+		})
+	}
+}
+
+// Make sure consumers are told about dropped events before they receive the next event
+func TestDeliverEvents_Overflow(t *testing.T) {
+	watcher := New()
+	watcher.FileChanged = nil
+	sub, _ := watcher.Subscribe(Filter{PathPrefix: "/some/folder"}, 10, Block)
+
+	q := newEventQueue(2, DropNewest)
+	for _, filePath := range []string{"/some/folder/1.txt", "/some/folder/2.txt", "/some/folder/3.txt"} {
+		q.push(FileEvent{FileChange: Add, FilePath: filePath})
+	}
+	close(q.events)
+	watcher.deliverEvents(q)
+
+	wantKinds := []FileChange{Overflow, Add, Add}
+	if len(sub.Events()) != len(wantKinds) {
+		t.Fatalf("subscriber should have received %d events, got %d", len(wantKinds), len(sub.Events()))
+	}
+	for _, wantKind := range wantKinds {
+		if event := <-sub.Events(); event.FileChange != wantKind {
+			t.Errorf("want %s event, got %s", wantKind, event.FileChange)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package server exposes a folderWatcher.Watcher over HTTP, so browsers and services written in other languages
+// can follow its events and manage its watches.
+//
+//	GET    /events             stream events as Server-Sent Events
+//	GET    /watches            list the watched folders
+//	POST   /watches            watch a folder
+//	DELETE /watches?path=...   stop watching a folder
+//	GET    /status             report the watcher's health, with status 503 unless it is ready
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// Time between the comments sent to keep idle event streams open
+const DefaultKeepAliveInterval = 15 * time.Second
+
+// Server is an http.Handler for a watcher
+type Server struct {
+	watcher *folderWatcher.Watcher
+	mux     *http.ServeMux
+	// number of events buffered for each client. A client which falls further behind is disconnected.
+	BufferSize        int
+	KeepAliveInterval time.Duration
+}
+
+func New(watcher *folderWatcher.Watcher) *Server {
+	s := &Server{
+		watcher:           watcher,
+		mux:               http.NewServeMux(),
+		BufferSize:        folderWatcher.DefaultSubscriptionBufferSize,
+		KeepAliveInterval: DefaultKeepAliveInterval,
+	}
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/watches", s.handleWatches)
+	s.mux.HandleFunc("/status", s.handleStatus)
+	return s
+}
+
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.mux.ServeHTTP(writer, request)
+}
+
+// watchJSON is the JSON form of a WatchRequest
+type watchJSON struct {
+	Path       string `json:"path"`
+	Recursive  bool   `json:"recursive"`
+	ShowHidden bool   `json:"showHidden"`
+}
+
+// statusJSON is the JSON form of a Status
+type statusJSON struct {
+	State            string           `json:"state"`
+	Ready            bool             `json:"ready"`
+	LastScan         *time.Time       `json:"lastScan,omitempty"`
+	LastScanDuration float64          `json:"lastScanDurationSeconds"`
+	QueueDepth       int              `json:"queueDepth"`
+	Roots            []rootStatusJSON `json:"roots"`
+}
+
+type rootStatusJSON struct {
+	Root              string `json:"root"`
+	Files             int    `json:"files"`
+	ConsecutiveErrors int    `json:"consecutiveErrors"`
+	LastError         string `json:"lastError,omitempty"`
+}
+
+// Write a JSON error response
+func writeError(writer http.ResponseWriter, status int, err error) {
+	writeJSON(writer, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(value)
+}
+
+// Create the subscription filter from the query. path is a path prefix, glob a pattern, and kind can be repeated or
+// hold a comma separated list of kinds.
+func parseFilter(request *http.Request) (filter folderWatcher.Filter, err error) {
+	query := request.URL.Query()
+	filter.PathPrefix = query.Get("path")
+	filter.Glob = query.Get("glob")
+	for _, kinds := range query["kind"] {
+		for _, kindName := range strings.Split(kinds, ",") {
+			kind, parseErr := folderWatcher.ParseFileChange(strings.TrimSpace(kindName))
+			if parseErr != nil {
+				err = parseErr
+				return
+			}
+			filter.Kinds = append(filter.Kinds, kind)
+		}
+	}
+	return
+}
+
+// Stream the events matching the filter until the client disconnects. Each event has the event's Sequence as its
+// id, its kind as the event type and its JSON encoding as the data.
+func (s *Server) handleEvents(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		writeError(writer, http.StatusMethodNotAllowed, errors.New("only GET is supported"))
+		return
+	}
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		writeError(writer, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	filter, err := parseFilter(request)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	// disconnect slow clients rather than holding up the watcher or other clients
+	sub, err := s.watcher.Subscribe(filter, s.BufferSize, folderWatcher.Disconnect)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(s.KeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				// the client fell behind and was disconnected
+				return
+			}
+			data, encodeErr := json.Marshal(event)
+			if encodeErr != nil {
+				continue
+			}
+			if _, err = fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.FileChange, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *Server) handleWatches(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		watches := make([]watchJSON, 0)
+		for _, watch := range s.watcher.Watches() {
+			watches = append(watches, watchJSON{Path: watch.Path, Recursive: watch.Recursive, ShowHidden: watch.ShowHidden})
+		}
+		writeJSON(writer, http.StatusOK, watches)
+
+	case http.MethodPost:
+		var watch watchJSON
+		if err := json.NewDecoder(request.Body).Decode(&watch); err != nil || watch.Path == "" {
+			writeError(writer, http.StatusBadRequest, errors.New("the body must be a JSON object with a path"))
+			return
+		}
+		if err := s.watcher.AddFolder(watch.Path, watch.Recursive, watch.ShowHidden); err != nil {
+			writeError(writer, http.StatusBadRequest, err)
+			return
+		}
+		watch.Path = folderWatcher.AbsPath(watch.Path)
+		writeJSON(writer, http.StatusCreated, watch)
+
+	case http.MethodDelete:
+		path := request.URL.Query().Get("path")
+		if path == "" {
+			writeError(writer, http.StatusBadRequest, errors.New("the path query parameter is required"))
+			return
+		}
+		if err := s.watcher.RemoveFolder(path, true); err != nil {
+			writeError(writer, http.StatusNotFound, err)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+
+	default:
+		writer.Header().Set("Allow", "GET, POST, DELETE")
+		writeError(writer, http.StatusMethodNotAllowed, errors.New(fmt.Sprintf("%s is not supported", request.Method)))
+	}
+}
+
+// Report the watcher's status. The response has status 503 unless the watcher is ready, so it can be used as a
+// readiness probe.
+func (s *Server) handleStatus(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		writeError(writer, http.StatusMethodNotAllowed, errors.New("only GET is supported"))
+		return
+	}
+
+	status := s.watcher.Status()
+	response := statusJSON{State: status.State.String(), Ready: status.Ready(), LastScanDuration: status.LastScanDuration.Seconds(),
+		QueueDepth: status.QueueDepth, Roots: make([]rootStatusJSON, 0, len(status.Roots))}
+	if !status.LastScan.IsZero() {
+		response.LastScan = &status.LastScan
+	}
+	for _, rootStatus := range status.Roots {
+		response.Roots = append(response.Roots, rootStatusJSON{Root: rootStatus.Root, Files: rootStatus.Files,
+			ConsecutiveErrors: rootStatus.ConsecutiveErrors, LastError: rootStatus.LastError})
+	}
+
+	responseStatus := http.StatusOK
+	if !status.Ready() {
+		responseStatus = http.StatusServiceUnavailable
+	}
+	writeJSON(writer, responseStatus, response)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+// Create a running watcher on a fake file system, with a server for it
+func newTestServer(t *testing.T) (watcher *folderWatcher.Watcher, fsys *folderwatchertest.FS, server *httptest.Server) {
+	fsys = folderwatchertest.NewFS()
+	fsys.Mkdir("/data/sub")
+	fsys.Mkdir("/other")
+	newWatcher := folderWatcher.New()
+	watcher = &newWatcher
+	watcher.FileSystem = fsys
+	watcher.Clock = folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	watcher.FileChanged = nil
+	_ = watcher.AddFolder("/data", true, false)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+
+	server = httptest.NewServer(New(watcher))
+	t.Cleanup(func() {
+		server.Close()
+		watcher.Stop()
+	})
+	return
+}
+
+func TestServer_Events(t *testing.T) {
+	watcher, fsys, server := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events?path=/data/sub&kind=Add,Write", nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type should be text/event-stream, got %s", contentType)
+	}
+
+	// only the Add in /data/sub matches the filter
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	_, _ = watcher.ScanNow(context.Background())
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	_, _ = watcher.ScanNow(context.Background())
+	_ = fsys.Remove("/data/sub/b.txt")
+	_, _ = watcher.ScanNow(context.Background())
+
+	reader := bufio.NewReader(response.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil {
+			t.Fatal(readErr.Error())
+		}
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if lines[0] != "id: 2" || lines[1] != "event: Add" {
+		t.Errorf("want the id and type of the Add event, got %v", lines[:2])
+	}
+	var event folderWatcher.FileEvent
+	if err = json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &event); err != nil {
+		t.Fatal(err.Error())
+	}
+	if event.FileChange != folderWatcher.Add || event.FilePath != folderWatcher.AbsPath("/data/sub/b.txt") {
+		t.Errorf("want the Add event for /data/sub/b.txt, got %s event for %s", event.FileChange, event.FilePath)
+	}
+}
+
+func TestServer_EventsBadRequest(t *testing.T) {
+	_, _, server := newTestServer(t)
+	for _, query := range []string{"kind=Created", "glob=["} {
+		response, err := http.Get(server.URL + "/events?" + query)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s should return status 400, got %d", query, response.StatusCode)
+		}
+	}
+}
+
+func TestServer_Watches(t *testing.T) {
+	watcher, _, server := newTestServer(t)
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		body       string
+		wantStatus int
+		wantPaths  []string
+	}{
+		{name: "list", method: http.MethodGet, wantStatus: http.StatusOK, wantPaths: []string{"/data"}},
+		{name: "add", method: http.MethodPost, body: `{"path":"/other","recursive":true}`, wantStatus: http.StatusCreated,
+			wantPaths: []string{"/data", "/other"}},
+		{name: "add missing folder", method: http.MethodPost, body: `{"path":"/missing"}`, wantStatus: http.StatusBadRequest,
+			wantPaths: []string{"/data", "/other"}},
+		{name: "add without a path", method: http.MethodPost, body: `{}`, wantStatus: http.StatusBadRequest,
+			wantPaths: []string{"/data", "/other"}},
+		{name: "remove", method: http.MethodDelete, query: "?path=/data", wantStatus: http.StatusNoContent, wantPaths: []string{"/other"}},
+		{name: "remove unwatched folder", method: http.MethodDelete, query: "?path=/data", wantStatus: http.StatusNotFound,
+			wantPaths: []string{"/other"}},
+		{name: "unsupported method", method: http.MethodPut, wantStatus: http.StatusMethodNotAllowed, wantPaths: []string{"/other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, _ := http.NewRequest(tt.method, server.URL+"/watches"+tt.query, strings.NewReader(tt.body))
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			defer response.Body.Close()
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("want status %d, got %d", tt.wantStatus, response.StatusCode)
+			}
+
+			watches := watcher.Watches()
+			if len(watches) != len(tt.wantPaths) {
+				t.Fatalf("want %d watches, got %v", len(tt.wantPaths), watches)
+			}
+			for i, watch := range watches {
+				if watch.Path != folderWatcher.AbsPath(tt.wantPaths[i]) {
+					t.Errorf("watch %d should be %s, got %s", i, folderWatcher.AbsPath(tt.wantPaths[i]), watch.Path)
+				}
+			}
+		})
+	}
+
+	// the list is returned as JSON
+	response, err := http.Get(server.URL + "/watches")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer response.Body.Close()
+	var listed []watchJSON
+	if err = json.NewDecoder(response.Body).Decode(&listed); err != nil || len(listed) != 1 || !listed[0].Recursive {
+		t.Errorf("GET /watches should list the recursive watch on /other, got %v, %v", listed, err)
+	}
+}
+
+func TestServer_Status(t *testing.T) {
+	watcher, fsys, server := newTestServer(t)
+	getStatus := func() (statusCode int, status statusJSON) {
+		response, err := http.Get(server.URL + "/status")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		defer response.Body.Close()
+		if err = json.NewDecoder(response.Body).Decode(&status); err != nil {
+			t.Fatal(err.Error())
+		}
+		return response.StatusCode, status
+	}
+
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	_, _ = watcher.ScanNow(context.Background())
+	statusCode, status := getStatus()
+	if statusCode != http.StatusOK || !status.Ready || status.State != "Running" || status.LastScan == nil {
+		t.Errorf("a running watcher should be ready, got status %d and %+v", statusCode, status)
+	}
+	if len(status.Roots) != 1 || status.Roots[0].Root != folderWatcher.AbsPath("/data") || status.Roots[0].Files != 1 {
+		t.Errorf("want 1 file watched for /data, got %+v", status.Roots)
+	}
+
+	_ = fsys.Remove("/data/a.txt")
+	_ = fsys.Remove("/data/sub")
+	_ = fsys.Remove("/data")
+	_, _ = watcher.ScanNow(context.Background())
+	if statusCode, status = getStatus(); statusCode != http.StatusServiceUnavailable || status.Ready || status.State != "Degraded" ||
+		status.Roots[0].ConsecutiveErrors != 1 {
+		t.Errorf("a watcher which cannot read /data should not be ready, got status %d and %+v", statusCode, status)
+	}
+}
@@ -0,0 +1,141 @@
+package folderWatcher
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// settlingFile is an Add or Write event withheld until the file stops changing
+type settlingFile struct {
+	event FileEvent
+	// the size and modification time when the file last changed, and when that was
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+	// number of scans since the file last changed
+	stableScans int
+}
+
+// Check if settling is enabled
+func (w *Watcher) isSettling() bool {
+	return w.SettleScans > 0 || w.SettleDuration > 0
+}
+
+// Get the current information for the file of an event, from the files found by the last scan
+func (w *Watcher) scannedFileInfo(event FileEvent) (fileInfo os.FileInfo, found bool) {
+	if watch, isFS := w.fsWatches[event.Root]; isFS {
+		fileInfo, found = watch.files[event.FilePath]
+	} else {
+		fileInfo, found = w.watchedFiles[event.FilePath]
+	}
+	return
+}
+
+// Withhold Add and Write events until the file's size and modification time have been stable for SettleScans scans
+// and SettleDuration, and no process has it open for writing when SettleCheckOpenWriters is set. The events of files
+// which have settled are returned with the other events, with the time and cycle of this scan. The scanMutex must be
+// held by the caller.
+func (w *Watcher) settle(events []FileEvent, scannedAt time.Time) (settledEvents []FileEvent) {
+	changedPaths := make(map[string]bool)
+	for _, event := range events {
+		switch event.FileChange {
+		case Add, Write:
+			changedPaths[event.FilePath] = true
+			w.addSettlingFile(event, scannedAt)
+			continue
+
+		case Move:
+			// the file keeps settling at its new path. Consumers were never told about a file which was added, so
+			// its move is not reported either.
+			if settling, found := w.settlingFiles[event.PreviousPath]; found {
+				delete(w.settlingFiles, event.PreviousPath)
+				changedPaths[event.FilePath] = true
+				settling.event.FilePath, settling.event.RelativePath = event.FilePath, event.RelativePath
+				settling.event.Description = fmt.Sprintf("%s updated", event.FilePath)
+				if settling.event.FileChange == Add {
+					settling.event.Description = fmt.Sprintf("%s created", event.FilePath)
+				}
+				w.settlingFiles[event.FilePath] = settling
+				if settling.event.FileChange == Add {
+					continue
+				}
+			}
+
+		case Remove:
+			// a file which was added and removed before it settled is not reported
+			if settling, found := w.settlingFiles[event.FilePath]; found {
+				delete(w.settlingFiles, event.FilePath)
+				if settling.event.FileChange == Add {
+					continue
+				}
+			}
+		}
+		settledEvents = append(settledEvents, event)
+	}
+
+	// find the files which have not changed since the last scan
+	var stablePaths []string
+	for filePath, settling := range w.settlingFiles {
+		if changedPaths[filePath] {
+			continue
+		}
+		fileInfo, found := w.scannedFileInfo(settling.event)
+		if !found {
+			// the watch was removed
+			delete(w.settlingFiles, filePath)
+			continue
+		}
+		if fileInfo.Size() != settling.size || !fileInfo.ModTime().Equal(settling.modTime) {
+			// compareFileLists only checks the modification time, so a change in size is found here
+			w.updateSettlingFile(settling, fileInfo, scannedAt)
+			continue
+		}
+		settling.stableScans++
+		if settling.stableScans >= w.SettleScans && scannedAt.Sub(settling.stableSince) >= w.SettleDuration {
+			stablePaths = append(stablePaths, filePath)
+		}
+	}
+
+	// files are only open for writing on the operating system's file systems
+	openPaths := make(map[string]bool)
+	if w.SettleCheckOpenWriters {
+		var osPaths []string
+		for _, filePath := range stablePaths {
+			if _, isFS := w.fsWatches[w.settlingFiles[filePath].event.Root]; !isFS {
+				osPaths = append(osPaths, filePath)
+			}
+		}
+		openPaths = findOpenWriters(osPaths)
+	}
+	for _, filePath := range stablePaths {
+		if openPaths[filePath] {
+			w.Logger.Debug("settled file is open for writing", "path", filePath)
+			continue
+		}
+		settled := w.settlingFiles[filePath].event
+		settled.DetectedAt, settled.Cycle = scannedAt, w.cycle
+		settledEvents = append(settledEvents, settled)
+		delete(w.settlingFiles, filePath)
+	}
+	return
+}
+
+// Start settling the file of an Add or Write event, or restart if it was already settling
+func (w *Watcher) addSettlingFile(event FileEvent, scannedAt time.Time) {
+	// an Add followed by writes is still an Add, and Old is the file before the first change
+	settling, found := w.settlingFiles[event.FilePath]
+	if !found {
+		settling = &settlingFile{event: event}
+		w.settlingFiles[event.FilePath] = settling
+	}
+	if fileInfo, found := w.scannedFileInfo(event); found {
+		w.updateSettlingFile(settling, fileInfo, scannedAt)
+	}
+}
+
+func (w *Watcher) updateSettlingFile(settling *settlingFile, fileInfo os.FileInfo, scannedAt time.Time) {
+	settling.size, settling.modTime = fileInfo.Size(), fileInfo.ModTime()
+	settling.event.ModTime, settling.event.New = fileInfo.ModTime(), newFileMetadata(fileInfo)
+	settling.stableSince, settling.stableScans = scannedAt, 0
+}
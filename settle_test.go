@@ -0,0 +1,123 @@
+package folderWatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+// Create a watcher for /data on a fake file system whose files are given the clock's time
+func newSettlingWatcher(settleScans int, settleDuration time.Duration) (watcher *Watcher, fsys *folderwatchertest.FS, clock *folderwatchertest.Clock) {
+	clock = folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	fsys = folderwatchertest.NewFS()
+	fsys.Now = clock.Now
+	fsys.Mkdir("/data")
+	newWatcher := New()
+	watcher = &newWatcher
+	watcher.FileSystem = fsys
+	watcher.Clock = clock
+	watcher.SettleScans = settleScans
+	watcher.SettleDuration = settleDuration
+	_ = watcher.AddFolder("/data", false, false)
+	return
+}
+
+func TestWatcher_SettleScans(t *testing.T) {
+	watcher, fsys, clock := newSettlingWatcher(2, 0)
+	tests := []struct {
+		name        string
+		changeFiles func()
+		wantEvents  int
+	}{
+		{name: "added", changeFiles: func() { fsys.WriteFile("/data/a.txt", []byte("a")) }, wantEvents: 0},
+		{name: "growing", changeFiles: func() { clock.Advance(time.Second); fsys.WriteFile("/data/a.txt", []byte("aa")) }, wantEvents: 0},
+		// the modification time is the same, but the size has changed
+		{name: "growing in the same second", changeFiles: func() { fsys.WriteFile("/data/a.txt", []byte("aaa")) }, wantEvents: 0},
+		{name: "stable for 1 scan", changeFiles: func() {}, wantEvents: 0},
+		{name: "stable for 2 scans", changeFiles: func() {}, wantEvents: 1},
+		{name: "settled", changeFiles: func() {}, wantEvents: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.changeFiles()
+			events, _ := watcher.ScanNow(context.Background())
+			if len(events) != tt.wantEvents {
+				t.Fatalf("ScanNow() should return %d events, got %v", tt.wantEvents, events)
+			}
+		})
+	}
+
+}
+
+func TestWatcher_SettleEvent(t *testing.T) {
+	watcher, fsys, clock := newSettlingWatcher(1, 0)
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	_, _ = watcher.ScanNow(context.Background())
+	clock.Advance(time.Second)
+	fsys.WriteFile("/data/a.txt", []byte("aa"))
+	_, _ = watcher.ScanNow(context.Background())
+
+	events, _ := watcher.ScanNow(context.Background())
+	if len(events) != 1 {
+		t.Fatalf("ScanNow() should return the settled event, got %v", events)
+	}
+	event := events[0]
+	if event.FileChange != Add || event.FilePath != AbsPath("/data/a.txt") || event.Old != nil || event.New == nil ||
+		event.New.Size != 2 || !event.ModTime.Equal(clock.Now()) || event.Cycle != 3 || event.Sequence != 1 {
+		t.Errorf("want an Add event with the final size of the file from cycle 3, got %+v", event)
+	}
+}
+
+// An expected change is dropped when it is found, not when the file has settled and the expectation has expired
+func TestWatcher_SettleExpected(t *testing.T) {
+	watcher, fsys, clock := newSettlingWatcher(0, 30*time.Second)
+	watcher.Expect("/data/a.txt", Add, DefaultExpectationTTL)
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	for scan := 1; scan <= 4; scan++ {
+		if events, _ := watcher.ScanNow(context.Background()); len(events) != 0 {
+			t.Fatalf("scan %d should not return the expected Add, got %v", scan, events)
+		}
+		clock.Advance(DefaultExpectationTTL)
+	}
+}
+
+func TestWatcher_SettleDuration(t *testing.T) {
+	watcher, fsys, clock := newSettlingWatcher(0, 5*time.Second)
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	for i, wantEvents := range []int{0, 0, 1} {
+		events, _ := watcher.ScanNow(context.Background())
+		if len(events) != wantEvents {
+			t.Errorf("scan %d should return %d events, got %v", i+1, wantEvents, events)
+		}
+		clock.Advance(3 * time.Second)
+	}
+}
+
+func TestWatcher_SettleMoveAndRemove(t *testing.T) {
+	watcher, fsys, _ := newSettlingWatcher(1, 0)
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/b.txt", []byte("b"))
+	_, _ = watcher.ScanNow(context.Background())
+
+	// a file added and moved before it settles is reported as added at the new path, and one added and removed is
+	// not reported at all
+	_ = fsys.Rename("/data/a.txt", "/data/moved.txt")
+	_ = fsys.Remove("/data/b.txt")
+	if events, _ := watcher.ScanNow(context.Background()); len(events) != 0 {
+		t.Errorf("the move and remove should not be reported, got %v", events)
+	}
+	events, _ := watcher.ScanNow(context.Background())
+	if len(events) != 1 || events[0].FileChange != Add || events[0].FilePath != AbsPath("/data/moved.txt") {
+		t.Errorf("want an Add event for /data/moved.txt, got %v", events)
+	}
+
+	// removing a file which was being written is reported
+	fsys.WriteFile("/data/moved.txt", []byte("updated"))
+	_, _ = watcher.ScanNow(context.Background())
+	_ = fsys.Remove("/data/moved.txt")
+	if events, _ = watcher.ScanNow(context.Background()); len(events) != 1 || events[0].FileChange != Remove {
+		t.Errorf("want a Remove event for /data/moved.txt, got %v", events)
+	}
+}
@@ -0,0 +1,136 @@
+package folderWatcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is a report of the watcher's health, for example for a readiness probe
+type Status struct {
+	// the WatcherState, except that a running watcher reports Degraded while a watch cannot be read, or otherwise
+	// Scanning while a scan is in progress
+	State WatcherState
+	// when the last completed scan finished, according to the Clock. Zero until a scan has completed.
+	LastScan         time.Time
+	LastScanDuration time.Duration
+	// number of events waiting for delivery
+	QueueDepth int
+	// one for each watched folder, file and file system, sorted by Root
+	Roots []RootStatus
+}
+
+// RootStatus is the status of one watch
+type RootStatus struct {
+	// the Root of the watch's events
+	Root string
+	// number of files being watched for the root
+	Files int
+	// number of scans in a row which could not read the watch, and the last error
+	ConsecutiveErrors int
+	LastError         string
+}
+
+// Ready reports if the watcher is running and every watch could be read by the last scan
+func (s Status) Ready() bool {
+	return s.State == Running || s.State == Scanning
+}
+
+// watcherStatus holds the details for Status. It has its own lock so Status does not wait for a scan to finish.
+type watcherStatus struct {
+	mutex            sync.Mutex
+	scanning         bool
+	lastScan         time.Time
+	lastScanDuration time.Duration
+	roots            map[string]RootStatus
+}
+
+func newWatcherStatus() *watcherStatus {
+	return &watcherStatus{roots: make(map[string]RootStatus)}
+}
+
+func (ws *watcherStatus) setScanning(scanning bool) {
+	ws.mutex.Lock()
+	ws.scanning = scanning
+	ws.mutex.Unlock()
+}
+
+func (ws *watcherStatus) setLastScan(finishedAt time.Time, duration time.Duration) {
+	ws.mutex.Lock()
+	ws.lastScan = finishedAt
+	ws.lastScanDuration = duration
+	ws.mutex.Unlock()
+}
+
+// Status reports the state of the watcher and of each watch. It does not wait for a scan in progress.
+func (w *Watcher) Status() (status Status) {
+	status.State = w.getState()
+	if queue := w.getQueue(); queue != nil {
+		status.QueueDepth = queue.depth()
+	}
+
+	w.status.mutex.Lock()
+	defer w.status.mutex.Unlock()
+	status.LastScan = w.status.lastScan
+	status.LastScanDuration = w.status.lastScanDuration
+	isDegraded := false
+	for _, rootStatus := range w.status.roots {
+		status.Roots = append(status.Roots, rootStatus)
+		isDegraded = isDegraded || rootStatus.ConsecutiveErrors > 0
+	}
+	sort.Slice(status.Roots, func(i, k int) bool { return status.Roots[i].Root < status.Roots[k].Root })
+
+	if status.State == Running && isDegraded {
+		status.State = Degraded
+	} else if status.State == Running && w.status.scanning {
+		status.State = Scanning
+	}
+	return
+}
+
+// Update the status of each watch. The errors are those of the scan which has just completed, by root; pass nil
+// when the watches have changed without a scan to keep the previous errors. The scanMutex must be held by the caller.
+func (w *Watcher) updateRootStatus(rootErrors map[string]error) {
+	roots := make(map[string]RootStatus)
+	for root := range w.RequestedWatches {
+		roots[root] = RootStatus{Root: root}
+	}
+	for root := range w.fileWatches {
+		roots[root] = RootStatus{Root: root}
+	}
+	for name, watch := range w.fsWatches {
+		roots[name] = RootStatus{Root: name, Files: len(watch.files)}
+	}
+
+	// count the files of each watch, giving file watches precedence as setWatchRoots does
+	w.watchedFileMutex.RLock()
+	for p := range w.watchedFiles {
+		root, found := p, w.fileWatches[p]
+		if !found {
+			root, found = findWatchRoot(w.RequestedWatches, p)
+		}
+		if found {
+			rootStatus := roots[root]
+			rootStatus.Files++
+			roots[root] = rootStatus
+		}
+	}
+	w.watchedFileMutex.RUnlock()
+
+	w.status.mutex.Lock()
+	defer w.status.mutex.Unlock()
+	for root, rootStatus := range roots {
+		previous := w.status.roots[root]
+		rootStatus.ConsecutiveErrors, rootStatus.LastError = previous.ConsecutiveErrors, previous.LastError
+		if rootErrors != nil {
+			if err := rootErrors[root]; err != nil {
+				rootStatus.ConsecutiveErrors++
+				rootStatus.LastError = err.Error()
+			} else {
+				rootStatus.ConsecutiveErrors = 0
+			}
+		}
+		roots[root] = rootStatus
+	}
+	w.status.roots = roots
+}
@@ -0,0 +1,162 @@
+package folderWatcher
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestWatcherState_String(t *testing.T) {
+	tests := []struct {
+		state WatcherState
+		want  string
+	}{
+		{NotStarted, "Not Started"},
+		{Running, "Running"},
+		{Stopped, "Stopped"},
+		{Paused, "Paused"},
+		{Degraded, "Degraded"},
+		{Scanning, "Scanning"},
+		{WatcherState(0), "WatcherState(0)"},
+		{WatcherState(7), "WatcherState(7)"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("WatcherState.String() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+// statusObserver records the watcher's status when a scan starts
+type statusObserver struct {
+	NopObserver
+	watcher *Watcher
+	states  []WatcherState
+}
+
+func (so *statusObserver) ScanStarted(cycle uint64) {
+	so.states = append(so.states, so.watcher.Status().State)
+}
+
+func TestWatcher_Status(t *testing.T) {
+	clock := folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	fsys.WriteFile("/data/sub/c.txt", []byte("c"))
+	fsys.WriteFile("/config.json", []byte("{}"))
+	fsys.Mkdir("/missing")
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Clock = clock
+	watcher.FileChanged = nil
+	observer := &statusObserver{watcher: &watcher}
+	watcher.Observer = observer
+	_ = watcher.AddFolder("/data", true, false)
+	_ = watcher.AddFolder("/data/sub", false, false)
+	_ = watcher.AddFolder("/missing", false, false)
+	_ = watcher.AddFile("/config.json")
+	_ = watcher.AddFS("archive", fstest.MapFS{"d.txt": &fstest.MapFile{Data: []byte("d")}}, WatchRequest{})
+
+	status := watcher.Status()
+	wantRoots := []RootStatus{
+		{Root: AbsPath("/config.json"), Files: 1},
+		{Root: AbsPath("/data"), Files: 1},
+		{Root: AbsPath("/data/sub"), Files: 2},
+		{Root: AbsPath("/missing")},
+		{Root: "archive", Files: 1},
+	}
+	if status.State != NotStarted || !status.LastScan.IsZero() || !reflect.DeepEqual(status.Roots, wantRoots) {
+		t.Errorf("Status() before the first scan = %+v, want NotStarted with roots %+v", status, wantRoots)
+	}
+
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	defer watcher.Stop()
+
+	// a watch which cannot be read degrades the watcher until it can be read again
+	_ = fsys.Remove("/missing")
+	for i := 1; i <= 2; i++ {
+		_, _ = watcher.ScanNow(context.Background())
+	}
+	status = watcher.Status()
+	missingStatus := status.Roots[3]
+	if status.State != Degraded || status.Ready() || missingStatus.ConsecutiveErrors != 2 || missingStatus.LastError == "" {
+		t.Errorf("Status() should be Degraded with 2 errors for /missing, got %s and %+v", status.State, missingStatus)
+	}
+	if !status.LastScan.Equal(clock.Now()) {
+		t.Errorf("Status() LastScan should be %s, got %s", clock.Now(), status.LastScan)
+	}
+
+	fsys.Mkdir("/missing")
+	_, _ = watcher.ScanNow(context.Background())
+	status = watcher.Status()
+	if status.State != Running || !status.Ready() || status.Roots[3].ConsecutiveErrors != 0 {
+		t.Errorf("Status() should be Running once /missing can be read, got %s and %+v", status.State, status.Roots[3])
+	}
+
+	wantStates := []WatcherState{Scanning, Degraded, Degraded}
+	if !reflect.DeepEqual(observer.states, wantStates) {
+		t.Errorf("the states during the scans should be %v, got %v", wantStates, observer.states)
+	}
+}
+
+// A watch nested in another watch has the errors of its own folder
+func TestWatcher_StatusNestedWatch(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/a/a.txt", []byte("a"))
+	fsys.WriteFile("/a/b/c.txt", []byte("c"))
+	watcher := New()
+	watcher.FileSystem = fsys
+	_ = watcher.AddFolder("/a", true, false)
+	_ = watcher.AddFolder("/a/b", false, false)
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	defer watcher.Stop()
+
+	tests := []struct {
+		name        string
+		changeFiles func()
+		wantErrors  []int
+		wantError   string
+	}{
+		{name: "nested folder removed", changeFiles: func() { _ = fsys.Remove("/a/b") }, wantErrors: []int{0, 1},
+			wantError: AbsPath("/a/b") + " is not a valid folder path"},
+		{name: "outer folder removed", changeFiles: func() { _ = fsys.Remove("/a") }, wantErrors: []int{1, 2},
+			wantError: AbsPath("/a/b") + " is not a valid folder path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.changeFiles()
+			_, _ = watcher.ScanNow(context.Background())
+			status := watcher.Status()
+			gotErrors := []int{status.Roots[0].ConsecutiveErrors, status.Roots[1].ConsecutiveErrors}
+			if status.State != Degraded || !reflect.DeepEqual(gotErrors, tt.wantErrors) || status.Roots[1].LastError != tt.wantError {
+				t.Errorf("Status() should be Degraded with errors %v and %q for /a/b, got %s with %+v",
+					tt.wantErrors, tt.wantError, status.State, status.Roots)
+			}
+		})
+	}
+}
+
+// Status can be called while the watcher is starting, as a readiness probe would
+func TestWatcher_StatusWhileStarting(t *testing.T) {
+	watcher := New()
+	watcher.FileSystem = folderwatchertest.NewFS()
+	watcher.Clock = folderwatchertest.NewClock(time.Now())
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			_ = watcher.Status()
+		}
+		close(done)
+	}()
+	go func() { <-watcher.Stopped }()
+	watcher.Start()
+	<-done
+	watcher.Stop()
+}
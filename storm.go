@@ -0,0 +1,66 @@
+package folderWatcher
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Check if a scan found a storm of changes, such as a checkout or package install, by comparing the number of
+// changes with StormEventsPerScan and the rate of changes since the previous scan with StormEventsPerSecond. The
+// scanMutex must be held by the caller.
+func (w *Watcher) isStorm(eventCount int, scannedAt time.Time) bool {
+	elapsed := scannedAt.Sub(w.lastScanAt)
+	isFirstScan := w.lastScanAt.IsZero()
+	w.lastScanAt = scannedAt
+
+	if eventCount == 0 {
+		return false
+	}
+	if w.StormEventsPerScan > 0 && eventCount > w.StormEventsPerScan {
+		return true
+	}
+	return w.StormEventsPerSecond > 0 && !isFirstScan && elapsed > 0 &&
+		float64(eventCount)/elapsed.Seconds() > w.StormEventsPerSecond
+}
+
+// Replace the events with a Storm event for each folder containing changed files. The events must already have
+// their roots set.
+func (w *Watcher) stormEvents(events []FileEvent) (stormEvents []FileEvent) {
+	type folderKey struct{ root, folder string }
+	changeCounts := make(map[folderKey]int)
+	relativeFolders := make(map[folderKey]string)
+	for _, event := range events {
+		// file systems added with AddFS use io/fs paths
+		dir := filepath.Dir
+		if _, isFS := w.fsWatches[event.Root]; isFS {
+			dir = path.Dir
+		}
+		key := folderKey{root: event.Root, folder: dir(event.FilePath)}
+		changeCounts[key]++
+		relativeFolders[key] = dir(event.RelativePath)
+
+		// a file moved from another folder of the watch also changes the folder it came from
+		previousKey := folderKey{root: event.Root, folder: dir(event.PreviousPath)}
+		if event.PreviousPath != "" && previousKey != key && !strings.HasPrefix(event.PreviousRelativePath, "..") {
+			changeCounts[previousKey]++
+			relativeFolders[previousKey] = dir(event.PreviousRelativePath)
+		}
+	}
+
+	for key, changeCount := range changeCounts {
+		stormEvents = append(stormEvents, FileEvent{FileChange: Storm, FilePath: key.folder, Root: key.root,
+			RelativePath: relativeFolders[key], DetectedAt: events[0].DetectedAt, Cycle: events[0].Cycle,
+			Description: fmt.Sprintf("%d changes in %s, a rescan is needed", changeCount, key.folder)})
+	}
+	sort.Slice(stormEvents, func(i, k int) bool {
+		if stormEvents[i].Root != stormEvents[k].Root {
+			return stormEvents[i].Root < stormEvents[k].Root
+		}
+		return stormEvents[i].FilePath < stormEvents[k].FilePath
+	})
+	return
+}
@@ -0,0 +1,92 @@
+package folderWatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestWatcher_StormEventsPerScan(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/sub/old.txt", []byte("old"))
+	logger := &recordingLogger{}
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Logger = logger
+	watcher.StormEventsPerScan = 3
+	_ = watcher.AddFolder("/data", true, false)
+
+	// the changes are summarized by folder once there are more than 3
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/b.txt", []byte("b"))
+	fsys.WriteFile("/data/sub/c.txt", []byte("c"))
+	_ = fsys.Rename("/data/sub/old.txt", "/data/new.txt")
+	events, _ := watcher.ScanNow(context.Background())
+	wantEvents := []FileEvent{
+		{FileChange: Storm, FilePath: AbsPath("/data"), RelativePath: ".", Description: "3 changes in " + AbsPath("/data") + ", a rescan is needed"},
+		{FileChange: Storm, FilePath: AbsPath("/data/sub"), RelativePath: "sub", Description: "2 changes in " + AbsPath("/data/sub") + ", a rescan is needed"},
+	}
+	if len(events) != len(wantEvents) {
+		t.Fatalf("ScanNow() should return %d Storm events, got %v", len(wantEvents), events)
+	}
+	for i, event := range events {
+		want := wantEvents[i]
+		if event.FileChange != want.FileChange || event.FilePath != want.FilePath || event.Root != AbsPath("/data") ||
+			event.RelativePath != want.RelativePath || event.Description != want.Description || event.Sequence != uint64(i+1) || event.Cycle != 1 {
+			t.Errorf("event %d should be %+v, got %+v", i, want, event)
+		}
+	}
+	if level, _, found := logger.find("storm started", "changes"); !found || level != "info" {
+		t.Error("the start of the storm should be logged")
+	}
+
+	// events are sent as normal once the changes subside
+	fsys.WriteFile("/data/d.txt", []byte("d"))
+	if events, _ = watcher.ScanNow(context.Background()); len(events) != 1 || events[0].FileChange != Add || events[0].Sequence != 3 {
+		t.Errorf("ScanNow() should return an Add event after the storm, got %v", events)
+	}
+	if _, _, found := logger.find("storm ended", "cycle"); !found {
+		t.Error("the end of the storm should be logged")
+	}
+}
+
+func TestWatcher_StormEventsPerSecond(t *testing.T) {
+	clock := folderwatchertest.NewClock(time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC))
+	fsys := folderwatchertest.NewFS()
+	fsys.Mkdir("/data")
+	watcher := New()
+	watcher.FileSystem = fsys
+	watcher.Clock = clock
+	watcher.StormEventsPerSecond = 2
+	_ = watcher.AddFolder("/data", false, false)
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		fileCount int
+		wantStorm bool
+	}{
+		// there is no rate for the first scan
+		{name: "first scan", advance: time.Second, fileCount: 5, wantStorm: false},
+		{name: "below rate", advance: 10 * time.Second, fileCount: 5, wantStorm: false},
+		{name: "above rate", advance: time.Second, fileCount: 3, wantStorm: true},
+		{name: "no changes", advance: time.Second, fileCount: 0, wantStorm: false},
+	}
+	fileNumber := 0
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(tt.advance)
+			for i := 0; i < tt.fileCount; i++ {
+				fileNumber++
+				fsys.WriteFile("/data/"+string(rune('a'+fileNumber))+".txt", []byte("data"))
+			}
+			events, _ := watcher.ScanNow(context.Background())
+			isStorm := len(events) == 1 && events[0].FileChange == Storm
+			if isStorm != tt.wantStorm || (!tt.wantStorm && len(events) != tt.fileCount) {
+				t.Errorf("want storm %v for %d changes, got %v", tt.wantStorm, tt.fileCount, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package folderWatcher
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Default number of events buffered for a subscriber when no buffer size is requested
+const DefaultSubscriptionBufferSize = 100
+
+// OverflowPolicy determines what happens to an event which cannot be delivered because a buffer is full
+type OverflowPolicy int
+
+const (
+	Block      OverflowPolicy = 0
+	DropOldest OverflowPolicy = 1
+	DropNewest OverflowPolicy = 2
+	Disconnect OverflowPolicy = 3
+)
+
+func (op OverflowPolicy) String() string {
+	policyStrings := [...]string{"Block", "DropOldest", "DropNewest", "Disconnect"}
+	if op < 0 || int(op) >= len(policyStrings) {
+		return fmt.Sprintf("OverflowPolicy(%d)", op)
+	}
+	return policyStrings[op]
+}
+
+// Filter describes the file events a subscriber is interested in. Fields left empty match every event.
+type Filter struct {
+	// only match events for paths equal to or below this path
+	PathPrefix string
+	// shell pattern (see filepath.Match). Patterns without a separator are matched against the file name,
+	// otherwise they are matched against the full path.
+	Glob string
+	// only match these kinds of file change
+	Kinds []FileChange
+}
+
+// Check that the filter can be used for matching
+func (f Filter) validate() (err error) {
+	if f.Glob != "" {
+		if _, err = filepath.Match(f.Glob, ""); err != nil {
+			err = errors.New(fmt.Sprintf("%s is not a valid glob pattern", f.Glob))
+		}
+	}
+	return
+}
+
+// Match reports whether the event passes the filter. Move events match on either the new or previous path.
+// Overflow events are only filtered by kind, and Storm events are not filtered by Glob because they are for a folder.
+func (f Filter) Match(event FileEvent) bool {
+	if len(f.Kinds) > 0 {
+		kindFound := false
+		for _, kind := range f.Kinds {
+			if kind == event.FileChange {
+				kindFound = true
+				break
+			}
+		}
+		if !kindFound {
+			return false
+		}
+	}
+
+	// an overflow affects every path, so it is not filtered by path
+	if event.FileChange == Overflow {
+		return true
+	}
+	// a storm affects unknown files in the folder, so it matches if the folder overlaps the prefix
+	if event.FileChange == Storm {
+		return f.matchFolder(event.FilePath)
+	}
+
+	if f.matchPath(event.FilePath) {
+		return true
+	}
+	return event.PreviousPath != "" && f.matchPath(event.PreviousPath)
+}
+
+func (f Filter) matchFolder(folderPath string) bool {
+	if f.PathPrefix == "" {
+		return true
+	}
+	prefix := filepath.Clean(f.PathPrefix)
+	return folderPath == prefix || isWithinFolder(folderPath, prefix) || isWithinFolder(prefix, folderPath)
+}
+
+func (f Filter) matchPath(filePath string) bool {
+	if f.PathPrefix != "" {
+		prefix := filepath.Clean(f.PathPrefix)
+		if filePath != prefix && !strings.HasPrefix(filePath, strings.TrimSuffix(prefix, string(filepath.Separator))+string(filepath.Separator)) {
+			return false
+		}
+	}
+
+	if f.Glob != "" {
+		name := filePath
+		if !strings.ContainsRune(f.Glob, filepath.Separator) && !strings.ContainsRune(f.Glob, '/') {
+			name = filepath.Base(filePath)
+		}
+		if matched, _ := filepath.Match(f.Glob, name); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Subscription is a consumer's own stream of file events.
+type Subscription interface {
+	// Events returns the channel the subscriber receives events on. The channel is closed when the
+	// subscription ends.
+	Events() <-chan FileEvent
+	// Unsubscribe stops delivery and closes the events channel. It is safe to call more than once.
+	Unsubscribe()
+}
+
+type subscription struct {
+	filter    Filter
+	policy    OverflowPolicy
+	events    chan FileEvent
+	done      chan struct{}
+	doneOnce  sync.Once
+	sendMutex sync.Mutex
+	closed    bool
+	list      *subscriberList
+}
+
+func (s *subscription) Events() <-chan FileEvent {
+	return s.events
+}
+
+func (s *subscription) Unsubscribe() {
+	// closing done first releases a send which is blocked on a full channel
+	s.doneOnce.Do(func() { close(s.done) })
+	s.list.remove(s)
+
+	s.sendMutex.Lock()
+	s.closeEvents()
+	s.sendMutex.Unlock()
+}
+
+// close the events channel, the sendMutex must be held by the caller
+func (s *subscription) closeEvents() {
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+// Deliver an event to the subscriber according to the overflow policy
+func (s *subscription) send(event FileEvent) {
+	if !s.filter.Match(event) {
+		return
+	}
+
+	s.sendMutex.Lock()
+	defer s.sendMutex.Unlock()
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case Block:
+		select {
+		case s.events <- event:
+		case <-s.done:
+		}
+	case DropOldest:
+		select {
+		case s.events <- event:
+		default:
+			// make room by discarding the oldest buffered event
+			select {
+			case <-s.events:
+			default:
+			}
+			select {
+			case s.events <- event:
+			default:
+			}
+		}
+	case DropNewest:
+		select {
+		case s.events <- event:
+		default:
+		}
+	case Disconnect:
+		select {
+		case s.events <- event:
+		default:
+			// the subscriber fell behind, so it is dropped
+			s.doneOnce.Do(func() { close(s.done) })
+			s.list.remove(s)
+			s.closeEvents()
+		}
+	}
+}
+
+// subscriberList holds the subscriptions for a watcher. It is shared by copies of the Watcher returned from New.
+type subscriberList struct {
+	mutex         sync.Mutex
+	subscriptions map[*subscription]bool
+}
+
+func newSubscriberList() *subscriberList {
+	return &subscriberList{subscriptions: make(map[*subscription]bool)}
+}
+
+func (sl *subscriberList) add(s *subscription) {
+	sl.mutex.Lock()
+	sl.subscriptions[s] = true
+	sl.mutex.Unlock()
+}
+
+func (sl *subscriberList) remove(s *subscription) {
+	sl.mutex.Lock()
+	delete(sl.subscriptions, s)
+	sl.mutex.Unlock()
+}
+
+// Send an event to every subscriber. The list is copied so a slow subscriber does not hold the lock.
+func (sl *subscriberList) publish(event FileEvent) {
+	sl.mutex.Lock()
+	subscriptions := make([]*subscription, 0, len(sl.subscriptions))
+	for s := range sl.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	sl.mutex.Unlock()
+
+	for _, s := range subscriptions {
+		s.send(event)
+	}
+}
+
+// EventSource is anything consumers can subscribe to, such as a Watcher or a client for a watcher in another
+// process. Code which only consumes events can accept an EventSource rather than a Watcher.
+type EventSource interface {
+	Subscribe(filter Filter, bufferSize int, policy OverflowPolicy) (Subscription, error)
+}
+
+// Subscribe returns a new Subscription which receives the events matching the filter on its own buffered
+// channel. The policy determines what happens when the subscriber's buffer is full. If bufferSize is less than
+// 1, DefaultSubscriptionBufferSize is used.
+func (w *Watcher) Subscribe(filter Filter, bufferSize int, policy OverflowPolicy) (sub Subscription, err error) {
+	if err = filter.validate(); err != nil {
+		return
+	}
+	if policy < Block || policy > Disconnect {
+		err = errors.New(fmt.Sprintf("%d is not a valid overflow policy", policy))
+		return
+	}
+	if bufferSize < 1 {
+		bufferSize = DefaultSubscriptionBufferSize
+	}
+
+	newSubscription := &subscription{
+		filter: filter,
+		policy: policy,
+		events: make(chan FileEvent, bufferSize),
+		done:   make(chan struct{}),
+		list:   w.subscribers,
+	}
+	w.subscribers.add(newSubscription)
+	sub = newSubscription
+	return
+}
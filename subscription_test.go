@@ -0,0 +1,129 @@
+package folderWatcher
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestFilter_Match(t *testing.T) {
+	root := AbsPath(testFolderPath)
+	tests := []struct {
+		name   string
+		filter Filter
+		event  FileEvent
+		want   bool
+	}{
+		{name: "empty filter", filter: Filter{}, event: FileEvent{FileChange: Add, FilePath: filepath.Join(root, "a.txt")}, want: true},
+		{name: "prefix match", filter: Filter{PathPrefix: root}, event: FileEvent{FileChange: Add, FilePath: filepath.Join(root, "a.txt")}, want: true},
+		{name: "prefix is not a folder boundary", filter: Filter{PathPrefix: root + "2"}, event: FileEvent{FileChange: Add, FilePath: filepath.Join(root, "a.txt")}, want: false},
+		{name: "glob on file name", filter: Filter{Glob: "*.txt"}, event: FileEvent{FileChange: Write, FilePath: filepath.Join(root, "sub", "a.txt")}, want: true},
+		{name: "glob does not match", filter: Filter{Glob: "*.log"}, event: FileEvent{FileChange: Write, FilePath: filepath.Join(root, "a.txt")}, want: false},
+		{name: "kind match", filter: Filter{Kinds: []FileChange{Remove, Move}}, event: FileEvent{FileChange: Remove, FilePath: "a.txt"}, want: true},
+		{name: "kind does not match", filter: Filter{Kinds: []FileChange{Remove}}, event: FileEvent{FileChange: Add, FilePath: "a.txt"}, want: false},
+		{name: "move matches previous path", filter: Filter{PathPrefix: filepath.Join(root, "sub")},
+			event: FileEvent{FileChange: Move, FilePath: filepath.Join(root, "b.txt"), PreviousPath: filepath.Join(root, "sub", "b.txt")}, want: true},
+		{name: "storm ignores glob", filter: Filter{Glob: "*.go"}, event: FileEvent{FileChange: Storm, FilePath: root}, want: true},
+		{name: "storm in folder below prefix", filter: Filter{PathPrefix: root}, event: FileEvent{FileChange: Storm, FilePath: filepath.Join(root, "sub")}, want: true},
+		{name: "storm in folder above prefix", filter: Filter{PathPrefix: filepath.Join(root, "sub")}, event: FileEvent{FileChange: Storm, FilePath: root}, want: true},
+		{name: "storm in other folder", filter: Filter{PathPrefix: filepath.Join(root, "sub")}, event: FileEvent{FileChange: Storm, FilePath: filepath.Join(root, "sub2")}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.event); got != tt.want {
+				t.Errorf("Filter.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverflowPolicy_String(t *testing.T) {
+	if got := DropNewest.String(); got != "DropNewest" {
+		t.Errorf("String() should return DropNewest, got %s", got)
+	}
+	if got := OverflowPolicy(7).String(); got != "OverflowPolicy(7)" {
+		t.Errorf("String() should return OverflowPolicy(7) for an unknown policy, got %s", got)
+	}
+}
+
+func TestSubscribe_InvalidArguments(t *testing.T) {
+	watcher := New()
+	if _, err := watcher.Subscribe(Filter{Glob: "[a-"}, 0, Block); err == nil {
+		t.Error("Subscribe() should return an error for an invalid glob")
+	}
+	if _, err := watcher.Subscribe(Filter{}, 0, OverflowPolicy(10)); err == nil {
+		t.Error("Subscribe() should return an error for an invalid overflow policy")
+	}
+}
+
+// Test the overflow policies for a subscriber which is not reading its channel
+func TestSubscribe_OverflowPolicy(t *testing.T) {
+	watcher := New()
+	dropOldest, _ := watcher.Subscribe(Filter{}, 2, DropOldest)
+	dropNewest, _ := watcher.Subscribe(Filter{}, 2, DropNewest)
+	disconnect, _ := watcher.Subscribe(Filter{}, 2, Disconnect)
+
+	for _, filePath := range []string{"1.txt", "2.txt", "3.txt"} {
+		watcher.subscribers.publish(FileEvent{FileChange: Add, FilePath: filePath})
+	}
+
+	if first := <-dropOldest.Events(); first.FilePath != "2.txt" {
+		t.Errorf("DropOldest subscriber should have discarded the first event, got %s", first.FilePath)
+	}
+	if first := <-dropNewest.Events(); first.FilePath != "1.txt" {
+		t.Errorf("DropNewest subscriber should have kept the first event, got %s", first.FilePath)
+	}
+
+	// the disconnected subscriber gets the buffered events followed by a closed channel
+	receivedCount := 0
+	for range disconnect.Events() {
+		receivedCount++
+	}
+	if receivedCount != 2 {
+		t.Errorf("Disconnect subscriber should have received 2 events before the channel closed, got %d", receivedCount)
+	}
+
+	// Unsubscribe closes the channel and can be called more than once
+	dropNewest.Unsubscribe()
+	dropNewest.Unsubscribe()
+	if len(watcher.subscribers.subscriptions) != 1 {
+		t.Errorf("there should be 1 subscriber left, got %d", len(watcher.subscribers.subscriptions))
+	}
+}
+
+// Test two subscribers with different filters receiving events from a running watcher
+func TestSubscribe_FanOut(t *testing.T) {
+	watcher := New()
+	watcher.FileChanged = nil
+	_ = watcher.AddFolder(testSubFolder, false, false)
+
+	allEvents, _ := watcher.Subscribe(Filter{}, 0, Block)
+	removeEvents, _ := watcher.Subscribe(Filter{Kinds: []FileChange{Remove}}, 0, Block)
+	defer allEvents.Unsubscribe()
+	defer removeEvents.Unsubscribe()
+
+	go func() { <-watcher.Stopped }()
+
+	// the clock is never advanced, so only ScanNow scans
+	watcher.Clock = folderwatchertest.NewClock(time.Now())
+	watcher.Start()
+	testFiles := createTestFiles(testSubFolder, 1)
+	_, _ = watcher.ScanNow(context.Background())
+	removeFiles(false, testFiles...)
+	_, _ = watcher.ScanNow(context.Background())
+	watcher.Flush()
+	watcher.Stop()
+
+	if len(allEvents.Events()) != 2 {
+		t.Errorf("subscriber without a filter should have received 2 events, got %d", len(allEvents.Events()))
+	}
+	if len(removeEvents.Events()) != 1 {
+		t.Errorf("subscriber filtered on Remove should have received 1 event, got %d", len(removeEvents.Events()))
+	}
+	if event := <-removeEvents.Events(); event.FileChange != Remove || event.FilePath != AbsPath(testFiles[0]) {
+		t.Errorf("subscriber filtered on Remove got the wrong event: %s %s", event.FileChange, event.FilePath)
+	}
+}
@@ -0,0 +1,180 @@
+package folderWatcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Check if the path is inside the folder, at any depth
+func isWithinFolder(filePath string, folderPath string) bool {
+	return strings.HasPrefix(filePath, strings.TrimSuffix(folderPath, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Check if the request includes the files directly in the folder
+func (wr WatchRequest) includesFilesIn(folderPath string) bool {
+	return folderPath == wr.Path || (wr.Recursive && isWithinFolder(folderPath, wr.Path))
+}
+
+// Check if the request needs the folder to be read, either for its files or to reach a deeper watched folder
+func (wr WatchRequest) needsFolder(folderPath string) bool {
+	return wr.includesFilesIn(folderPath) || isWithinFolder(wr.Path, folderPath)
+}
+
+// Check if the file is one of the files the request watches
+func (wr WatchRequest) covers(filePath string) bool {
+	relativePath, err := filepath.Rel(wr.Path, filePath)
+	if err != nil || relativePath == "." || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return false
+	}
+	if !wr.Recursive && strings.ContainsRune(relativePath, filepath.Separator) {
+		return false
+	}
+	return wr.ShowHidden || !isHiddenFile(filePath)
+}
+
+// Find the watch the file belongs to. When watches overlap, the file belongs to the one with the deepest folder.
+func findWatchRoot(requests map[string]WatchRequest, filePath string) (root string, found bool) {
+	for requestPath, request := range requests {
+		if len(requestPath) > len(root) && request.covers(filePath) {
+			root, found = requestPath, true
+		}
+	}
+	return
+}
+
+// Set the Root and relative paths of the operating system's events
+func (w *Watcher) setWatchRoots(events []FileEvent) {
+	for i, event := range events {
+		root, found := findWatchRoot(w.RequestedWatches, event.FilePath)
+		folderPath := root
+		if w.fileWatches[event.FilePath] {
+			// a file added with AddFile belongs to its own watch, with paths relative to the folder containing it
+			root, folderPath, found = event.FilePath, filepath.Dir(event.FilePath), true
+		}
+		if !found {
+			continue
+		}
+		events[i].Root = root
+		events[i].RelativePath, _ = filepath.Rel(folderPath, event.FilePath)
+		if event.PreviousPath != "" {
+			events[i].PreviousRelativePath, _ = filepath.Rel(folderPath, event.PreviousPath)
+		}
+	}
+}
+
+// Set the Root and relative paths of the events for a file system watch. The paths in the file system always use
+// forward slashes.
+func (fw *fsWatch) setWatchRoots(events []FileEvent) {
+	relativePath := func(filePath string) string {
+		if fw.request.Path == "." {
+			return filePath
+		}
+		return strings.TrimPrefix(filePath, fw.request.Path+"/")
+	}
+	for i, event := range events {
+		events[i].Root = fw.name
+		events[i].RelativePath = relativePath(event.FilePath)
+		if event.PreviousPath != "" {
+			events[i].PreviousRelativePath = relativePath(event.PreviousPath)
+		}
+	}
+}
+
+// Find the folders where scanning starts. A watched folder inside another watched folder is reached by scanning the
+// outer folder, so each folder is read once per scan however many watches include it.
+func scanRoots(requests map[string]WatchRequest) (roots []string) {
+	for requestPath := range requests {
+		isNested := false
+		for otherPath := range requests {
+			if isWithinFolder(requestPath, otherPath) {
+				isNested = true
+				break
+			}
+		}
+		if !isNested {
+			roots = append(roots, requestPath)
+		}
+	}
+	return
+}
+
+// Get the files below the root which are included by any of the requests, and the number of folders read
+func getWatchedFileList(fsys FileSystem, logger Logger, rootPath string, requests []WatchRequest) (fileList map[string]os.FileInfo, folderCount int, err error) {
+	if !isValidDirPathIn(fsys, rootPath) {
+		err = errors.New(fmt.Sprintf("%s is not a valid folder path", rootPath))
+		return
+	}
+	fileList = make(map[string]os.FileInfo)
+	err = addWatchedFolderFiles(fsys, logger, rootPath, requests, fileList, &folderCount)
+	return
+}
+
+// Get the errors of the watches nested below the root, by each watch's own path. A watch whose folder is missing or is
+// not a folder has its own error, and the others fail with the root.
+func nestedWatchErrors(fsys FileSystem, rootPath string, requests []WatchRequest, rootErr error) (errs map[string]error) {
+	errs = make(map[string]error)
+	for _, request := range requests {
+		if !isWithinFolder(request.Path, rootPath) {
+			continue
+		}
+		if !isValidDirPathIn(fsys, request.Path) {
+			errs[request.Path] = errors.New(fmt.Sprintf("%s is not a valid folder path", request.Path))
+		} else if rootErr != nil {
+			errs[request.Path] = errors.New(fmt.Sprintf("%s was not scanned: %s", request.Path, rootErr.Error()))
+		}
+	}
+	return
+}
+
+func addWatchedFolderFiles(fsys FileSystem, logger Logger, folderPath string, requests []WatchRequest, fileList map[string]os.FileInfo, folderCount *int) (err error) {
+	entries, err := fsys.ReadDir(folderPath)
+	if err != nil {
+		return
+	}
+	*folderCount++
+
+	// hidden files are included if any of the requests including the folder's files shows them
+	includeFiles, showHidden := false, false
+	for _, request := range requests {
+		if request.includesFilesIn(folderPath) {
+			includeFiles = true
+			showHidden = showHidden || request.ShowHidden
+		}
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(folderPath, entry.Name())
+		if entry.IsDir() {
+			for _, request := range requests {
+				if request.needsFolder(entryPath) {
+					if err = addWatchedFolderFiles(fsys, logger, entryPath, requests, fileList, folderCount); err != nil && !errors.Is(err, fs.ErrNotExist) {
+						return
+					} else if err != nil {
+						logger.Debug("skipped folder removed while scanning", "path", entryPath)
+					}
+					err = nil
+					break
+				}
+			}
+			continue
+		}
+
+		if !includeFiles {
+			continue
+		}
+		fileInfo, infoErr := entry.Info()
+		if infoErr != nil {
+			// the file was removed after the folder was read
+			logger.Debug("skipped file removed while scanning", "path", entryPath, "error", infoErr)
+			continue
+		}
+		if showHidden || !isHiddenFile(entryPath) {
+			fileList[entryPath] = fileInfo
+		}
+	}
+	return
+}
@@ -0,0 +1,183 @@
+package folderWatcher
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/mikerapa/FolderWatcher/folderwatchertest"
+)
+
+func TestFindWatchRoot(t *testing.T) {
+	requests := make(map[string]WatchRequest)
+	for _, request := range []WatchRequest{
+		{Path: AbsPath("/data"), Recursive: true, ShowHidden: true},
+		{Path: AbsPath("/data/sub"), Recursive: true},
+		{Path: AbsPath("/data/sub/flat"), Recursive: false},
+	} {
+		requests[request.Path] = request
+	}
+
+	tests := []struct {
+		name      string
+		filePath  string
+		wantRoot  string
+		wantFound bool
+		unixOnly  bool
+	}{
+		{name: "one watch", filePath: "/data/a.txt", wantRoot: "/data", wantFound: true},
+		{name: "deepest watch", filePath: "/data/sub/deeper/a.txt", wantRoot: "/data/sub", wantFound: true},
+		{name: "non-recursive watch", filePath: "/data/sub/flat/a.txt", wantRoot: "/data/sub/flat", wantFound: true},
+		{name: "below non-recursive watch", filePath: "/data/sub/flat/deeper/a.txt", wantRoot: "/data/sub", wantFound: true},
+		{name: "hidden file", filePath: "/data/sub/.a.txt", wantRoot: "/data", wantFound: true, unixOnly: true},
+		{name: "similar folder name", filePath: "/data/subway/a.txt", wantRoot: "/data", wantFound: true},
+		{name: "not watched", filePath: "/other/a.txt", wantFound: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.unixOnly && runtime.GOOS == "windows" {
+				t.Skip("hidden files are not based on the name on Windows")
+			}
+			root, found := findWatchRoot(requests, AbsPath(tt.filePath))
+			if found != tt.wantFound {
+				t.Fatalf("findWatchRoot() found = %v, want %v", found, tt.wantFound)
+			}
+			if found && root != AbsPath(tt.wantRoot) {
+				t.Errorf("findWatchRoot() = %s, want %s", root, AbsPath(tt.wantRoot))
+			}
+		})
+	}
+}
+
+// A change in overlapping watches is only reported once, for the deepest watch
+func TestScanNow_OverlappingWatches(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.Mkdir("/data/sub")
+	watcher := New()
+	watcher.FileSystem = fsys
+	_ = watcher.AddFolder("/data", true, false)
+	_ = watcher.AddFolder("/data/sub", true, false)
+
+	fsys.WriteFile("/data/sub/a.txt", []byte("a"))
+	fsys.WriteFile("/data/b.txt", []byte("b"))
+	events, _ := watcher.ScanNow(context.Background())
+	if len(events) != 2 {
+		t.Fatalf("ScanNow() should return 2 events, got %d", len(events))
+	}
+	wantRoots := map[string]string{AbsPath("/data/sub/a.txt"): AbsPath("/data/sub"), AbsPath("/data/b.txt"): AbsPath("/data")}
+	for _, event := range events {
+		if event.Root != wantRoots[event.FilePath] {
+			t.Errorf("event for %s should have Root %s, got %s", event.FilePath, wantRoots[event.FilePath], event.Root)
+		}
+		if event.RelativePath != filepath.Base(event.FilePath) {
+			t.Errorf("event for %s should have RelativePath %s, got %s", event.FilePath, filepath.Base(event.FilePath), event.RelativePath)
+		}
+	}
+
+	// a file moved into the deeper watch has a previous path relative to it
+	_ = fsys.Rename("/data/b.txt", "/data/sub/b.txt")
+	events, _ = watcher.ScanNow(context.Background())
+	wantPrevious := filepath.Join("..", "b.txt")
+	if len(events) != 1 || events[0].FileChange != Move || events[0].PreviousRelativePath != wantPrevious {
+		t.Errorf("ScanNow() should return a Move event with PreviousRelativePath %s, got %v", wantPrevious, events)
+	}
+}
+
+func TestFSWatch_SetWatchRoots(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		filePath string
+		want     string
+	}{
+		{name: "whole file system", path: ".", filePath: "data/a.txt", want: "data/a.txt"},
+		{name: "folder", path: "data", filePath: "data/sub/a.txt", want: "sub/a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watch := &fsWatch{name: "archive", request: WatchRequest{Path: tt.path}}
+			events := []FileEvent{{FileChange: Move, FilePath: tt.filePath, PreviousPath: tt.filePath}}
+			watch.setWatchRoots(events)
+			if events[0].Root != "archive" || events[0].RelativePath != tt.want || events[0].PreviousRelativePath != tt.want {
+				t.Errorf("want Root archive and relative paths %s, got %s, %s and %s", tt.want, events[0].Root,
+					events[0].RelativePath, events[0].PreviousRelativePath)
+			}
+		})
+	}
+}
+
+// countingFS counts the number of times each folder is read
+type countingFS struct {
+	*folderwatchertest.FS
+	reads map[string]int
+}
+
+func (cfs *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	cfs.reads[name]++
+	return cfs.FS.ReadDir(name)
+}
+
+// Every folder is read once per scan, even when watches overlap
+func TestScanNow_ReadsFoldersOnce(t *testing.T) {
+	fsys := &countingFS{FS: folderwatchertest.NewFS(), reads: make(map[string]int)}
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	fsys.WriteFile("/data/sub/deeper/c.txt", []byte("c"))
+	fsys.WriteFile("/data/other/d.txt", []byte("d"))
+	watcher := New()
+	watcher.FileSystem = fsys
+	_ = watcher.AddFolder("/data", false, false)
+	_ = watcher.AddFolder("/data/sub", true, false)
+	_ = watcher.AddFolder("/data/sub/deeper", true, false)
+
+	fsys.reads = make(map[string]int)
+	_, _ = watcher.ScanNow(context.Background())
+	wantReads := map[string]int{AbsPath("/data"): 1, AbsPath("/data/sub"): 1, AbsPath("/data/sub/deeper"): 1}
+	if !reflect.DeepEqual(fsys.reads, wantReads) {
+		t.Errorf("ScanNow() should read %v, got %v", wantReads, fsys.reads)
+	}
+	if len(watcher.watchedFiles) != 3 {
+		t.Errorf("should be watching 3 files, got %d", len(watcher.watchedFiles))
+	}
+}
+
+// Removing a watch keeps the files another watch still includes
+func TestWatcher_RemoveOverlappingFolder(t *testing.T) {
+	fsys := folderwatchertest.NewFS()
+	fsys.WriteFile("/data/a.txt", []byte("a"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("b"))
+	fsys.WriteFile("/data/sub/deeper/c.txt", []byte("c"))
+
+	tests := []struct {
+		name          string
+		parentRequest WatchRequest
+		wantFileCount int
+	}{
+		{name: "recursive parent", parentRequest: WatchRequest{Path: "/data", Recursive: true}, wantFileCount: 3},
+		{name: "non-recursive parent", parentRequest: WatchRequest{Path: "/data", Recursive: false}, wantFileCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := New()
+			watcher.FileSystem = fsys
+			_ = watcher.AddFolder(tt.parentRequest.Path, tt.parentRequest.Recursive, false)
+			_ = watcher.AddFolder("/data/sub", true, false)
+			if len(watcher.watchedFiles) != 3 {
+				t.Fatalf("should be watching 3 files, got %d", len(watcher.watchedFiles))
+			}
+
+			if err := watcher.RemoveFolder("/data/sub", true); err != nil {
+				t.Fatal(err.Error())
+			}
+			if len(watcher.watchedFiles) != tt.wantFileCount {
+				t.Errorf("should be watching %d files after RemoveFolder, got %d", tt.wantFileCount, len(watcher.watchedFiles))
+			}
+			if events, _ := watcher.ScanNow(context.Background()); len(events) != 0 {
+				t.Errorf("ScanNow() should not find changes after RemoveFolder, got %v", events)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// batch is a group of events sent in one request
+type batch struct {
+	id     uint64
+	events []folderWatcher.FileEvent
+}
+
+// retryQueue holds the batches which have not been delivered yet, oldest first. When it has a folder, each batch
+// is also written to a file there until it is delivered, so it survives a restart.
+type retryQueue struct {
+	mutex   sync.Mutex
+	folder  string
+	batches []batch
+	lastID  uint64
+}
+
+const batchFileExtension = ".jsonl"
+
+// Create the queue, loading the batches left in the folder by an earlier run
+func newRetryQueue(folder string) (rq *retryQueue, err error) {
+	rq = &retryQueue{folder: folder}
+	if folder == "" {
+		return
+	}
+	if err = os.MkdirAll(folder, 0700); err != nil {
+		return
+	}
+
+	// the files are named after the batch ids, so reading the folder in name order gives the oldest first
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		idText := strings.TrimSuffix(entry.Name(), batchFileExtension)
+		id, parseErr := strconv.ParseUint(idText, 10, 64)
+		if entry.IsDir() || idText == entry.Name() || parseErr != nil {
+			continue
+		}
+		b := batch{id: id}
+		if b.events, err = readBatchFile(filepath.Join(folder, entry.Name())); err != nil {
+			return
+		}
+		rq.batches = append(rq.batches, b)
+		rq.lastID = id
+	}
+	return
+}
+
+func (rq *retryQueue) batchFilePath(id uint64) string {
+	return filepath.Join(rq.folder, fmt.Sprintf("%020d%s", id, batchFileExtension))
+}
+
+// Add the events to the end of the queue
+func (rq *retryQueue) add(events []folderWatcher.FileEvent) (err error) {
+	rq.mutex.Lock()
+	defer rq.mutex.Unlock()
+
+	rq.lastID++
+	b := batch{id: rq.lastID, events: events}
+	if rq.folder != "" {
+		if err = writeBatchFile(rq.batchFilePath(b.id), events); err != nil {
+			return
+		}
+	}
+	rq.batches = append(rq.batches, b)
+	return
+}
+
+// Get the oldest batch without removing it
+func (rq *retryQueue) first() (b batch, found bool) {
+	rq.mutex.Lock()
+	defer rq.mutex.Unlock()
+
+	if len(rq.batches) == 0 {
+		return
+	}
+	return rq.batches[0], true
+}
+
+// Remove the oldest batch, once it has been delivered or can never be delivered
+func (rq *retryQueue) remove(id uint64) (err error) {
+	rq.mutex.Lock()
+	defer rq.mutex.Unlock()
+
+	if len(rq.batches) == 0 || rq.batches[0].id != id {
+		return
+	}
+	rq.batches = rq.batches[1:]
+	if rq.folder != "" {
+		if err = os.Remove(rq.batchFilePath(id)); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	}
+	return
+}
+
+// Number of batches waiting for delivery
+func (rq *retryQueue) length() int {
+	rq.mutex.Lock()
+	defer rq.mutex.Unlock()
+	return len(rq.batches)
+}
+
+// Write the batch to a temporary file and rename it, so a partly written batch is never loaded
+func writeBatchFile(filePath string, events []folderWatcher.FileEvent) (err error) {
+	tempPath := filePath + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	writer := folderWatcher.NewEventWriter(file)
+	for _, event := range events {
+		if err = writer.Write(event); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return
+	}
+	return os.Rename(tempPath, filePath)
+}
+
+func readBatchFile(filePath string) (events []folderWatcher.FileEvent, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	reader := folderWatcher.NewEventReader(file)
+	for {
+		event, readErr := reader.Read()
+		if readErr == io.EOF {
+			return
+		}
+		if readErr != nil {
+			err = errors.New(fmt.Sprintf("cannot read the queued events in %s: %s", filePath, readErr.Error()))
+			return
+		}
+		events = append(events, event)
+	}
+}
@@ -0,0 +1,269 @@
+// Package webhook delivers folderWatcher events to an HTTP endpoint. Events are sent as JSON, singly or in batches,
+// and signed with HMAC-SHA256 when a secret is configured. Failed requests are retried with exponential backoff, and
+// undelivered events can be kept on disk so they survive a restart.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// Header holding the signature of the request body
+const SignatureHeader = "X-FolderWatcher-Signature"
+
+// Defaults used for the Config fields which are not set
+const (
+	DefaultTimeout        = 10 * time.Second
+	DefaultMaxRetries     = 5
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultBatchDelay     = time.Second
+)
+
+type Config struct {
+	// the URL the events are posted to
+	URL string
+	// key used to sign each request. Requests are not signed when it is empty.
+	Secret []byte
+	// maximum number of events in one request. When it is 0 or 1, each request has a single event object rather
+	// than an array of events.
+	BatchSize int
+	// longest time to wait for a batch to fill before it is sent
+	BatchDelay time.Duration
+	// time limit for each request
+	Timeout time.Duration
+	// number of times a failed request is retried before the sink waits for the next event, or MaxBackoff, to try
+	// again. Set it to a negative number to disable retries.
+	MaxRetries int
+	// the delay before the first retry, which doubles for each retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// folder where undelivered events are kept. They are only kept in memory when it is empty.
+	QueueFolder string
+	// client used to send the requests, http.DefaultClient when nil
+	Client *http.Client
+	// called when a request fails, optional
+	OnError func(err error)
+}
+
+// Sink posts events to a URL
+type Sink struct {
+	config Config
+	queue  *retryQueue
+}
+
+// New creates a sink. Events left in the QueueFolder by an earlier sink are sent before any new events.
+func New(config Config) (sink *Sink, err error) {
+	if config.URL == "" {
+		err = errors.New("a URL is required to send events")
+		return
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	if config.BatchDelay <= 0 {
+		config.BatchDelay = DefaultBatchDelay
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = DefaultInitialBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	sink = &Sink{config: config}
+	if sink.queue, err = newRetryQueue(config.QueueFolder); err != nil {
+		sink = nil
+	}
+	return
+}
+
+// Signature returns the value of the SignatureHeader for a request body, so receivers can check it with hmac.Equal
+func Signature(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Pending returns the number of batches waiting to be delivered
+func (s *Sink) Pending() int {
+	return s.queue.length()
+}
+
+// Run sends the events from the channel, such as the Events channel of a Subscription, until the channel is closed
+// or the context is cancelled. Events are not read from the channel while a request is being retried. The events
+// which could not be delivered stay queued for the next call to Run.
+func (s *Sink) Run(ctx context.Context, events <-chan folderWatcher.FileEvent) (err error) {
+	var pendingEvents []folderWatcher.FileEvent
+	var batchTimer, retryTimer <-chan time.Time
+
+	// add the events waiting for the batch to fill to the queue, and try to send everything in the queue
+	sendPending := func() {
+		batchTimer = nil
+		if len(pendingEvents) > 0 {
+			if queueErr := s.queue.add(pendingEvents); queueErr != nil {
+				s.reportError(queueErr)
+			}
+			pendingEvents = nil
+		}
+		retryTimer = nil
+		if !s.deliverQueued(ctx) {
+			retryTimer = time.After(s.config.MaxBackoff)
+		}
+	}
+
+	// send anything left from an earlier run
+	sendPending()
+	for {
+		select {
+		case <-ctx.Done():
+			if len(pendingEvents) > 0 {
+				if queueErr := s.queue.add(pendingEvents); queueErr != nil {
+					s.reportError(queueErr)
+				}
+			}
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				sendPending()
+				return
+			}
+			pendingEvents = append(pendingEvents, event)
+			if len(pendingEvents) >= s.config.BatchSize {
+				sendPending()
+			} else if batchTimer == nil {
+				batchTimer = time.After(s.config.BatchDelay)
+			}
+		case <-batchTimer:
+			sendPending()
+		case <-retryTimer:
+			sendPending()
+		}
+	}
+}
+
+// Send the queued batches in order, and report if the queue was emptied
+func (s *Sink) deliverQueued(ctx context.Context) bool {
+	for {
+		b, found := s.queue.first()
+		if !found {
+			return true
+		}
+		err := s.deliver(ctx, b.events)
+		var permanentErr *permanentError
+		if err != nil && !errors.As(err, &permanentErr) {
+			// try again later, keeping the batch at the front of the queue so the events stay in order
+			return false
+		}
+		if err = s.queue.remove(b.id); err != nil {
+			s.reportError(err)
+		}
+	}
+}
+
+// permanentError is a failure which retrying will not fix, such as the request being rejected. The events are
+// dropped.
+type permanentError struct {
+	reason string
+}
+
+func (pe *permanentError) Error() string {
+	return fmt.Sprintf("%s, the events will not be sent again", pe.reason)
+}
+
+// Post the events, retrying with exponential backoff
+func (s *Sink) deliver(ctx context.Context, events []folderWatcher.FileEvent) (err error) {
+	var body []byte
+	if s.config.BatchSize == 1 && len(events) == 1 {
+		body, err = json.Marshal(events[0])
+	} else {
+		body, err = json.Marshal(events)
+	}
+	if err != nil {
+		err = &permanentError{reason: fmt.Sprintf("cannot encode the events: %s", err.Error())}
+		s.reportError(err)
+		return
+	}
+
+	backoff := s.config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		if err = s.post(ctx, body); err == nil {
+			return
+		}
+		s.reportError(err)
+		var permanentErr *permanentError
+		if errors.As(err, &permanentErr) || attempt >= s.config.MaxRetries {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// Send one request. Client errors other than timeouts and rate limiting are permanent.
+func (s *Sink) post(ctx context.Context, body []byte) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(s.config.Secret) > 0 {
+		request.Header.Set(SignatureHeader, Signature(s.config.Secret, body))
+	}
+
+	response, err := s.config.Client.Do(request)
+	if err != nil {
+		return
+	}
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	_ = response.Body.Close()
+
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return nil
+	case response.StatusCode >= 400 && response.StatusCode < 500 &&
+		response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests:
+		return &permanentError{reason: fmt.Sprintf("%s rejected the events with status %d", s.config.URL, response.StatusCode)}
+	default:
+		return errors.New(fmt.Sprintf("%s returned status %d", s.config.URL, response.StatusCode))
+	}
+}
+
+func (s *Sink) reportError(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikerapa/FolderWatcher"
+)
+
+// receiver is a test server which records the requests it receives
+type receiver struct {
+	mutex    sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int
+}
+
+// Respond with the next status, or 200 once they run out
+func (r *receiver) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	body, _ := ioutil.ReadAll(request.Body)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	if status == http.StatusOK {
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, request.Header.Clone())
+	}
+	writer.WriteHeader(status)
+}
+
+func (r *receiver) received() (bodies [][]byte, headers []http.Header) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.bodies, r.headers
+}
+
+func testEvents(count int) (events []folderWatcher.FileEvent) {
+	for i := 1; i <= count; i++ {
+		events = append(events, folderWatcher.FileEvent{FileChange: folderWatcher.Add, FilePath: "/data/a.txt", Sequence: uint64(i)})
+	}
+	return
+}
+
+// Send the events and close the channel, so Run returns once they are delivered
+func runSink(t *testing.T, sink *Sink, events []folderWatcher.FileEvent) {
+	channel := make(chan folderWatcher.FileEvent, len(events))
+	for _, event := range events {
+		channel <- event
+	}
+	close(channel)
+	if err := sink.Run(context.Background(), channel); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("New() should return an error without a URL")
+	}
+	sink, err := New(Config{URL: "http://localhost"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if sink.config.BatchSize != 1 || sink.config.MaxRetries != DefaultMaxRetries || sink.config.Timeout != DefaultTimeout {
+		t.Errorf("New() should use the defaults, got %+v", sink.config)
+	}
+}
+
+func TestSink_Run(t *testing.T) {
+	tests := []struct {
+		name          string
+		batchSize     int
+		eventCount    int
+		statuses      []int
+		wantRequests  int
+		wantLastCount int
+	}{
+		{name: "single events", batchSize: 1, eventCount: 3, wantRequests: 3, wantLastCount: 1},
+		{name: "batches", batchSize: 2, eventCount: 3, wantRequests: 2, wantLastCount: 1},
+		{name: "retried", batchSize: 1, eventCount: 1, statuses: []int{500, 503, 429}, wantRequests: 1, wantLastCount: 1},
+		{name: "rejected events are dropped", batchSize: 1, eventCount: 2, statuses: []int{400}, wantRequests: 1, wantLastCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(r)
+			defer server.Close()
+
+			var errorCount int
+			sink, _ := New(Config{URL: server.URL, BatchSize: tt.batchSize, InitialBackoff: time.Millisecond,
+				OnError: func(err error) { errorCount++ }})
+			runSink(t, sink, testEvents(tt.eventCount))
+
+			bodies, headers := r.received()
+			if len(bodies) != tt.wantRequests {
+				t.Fatalf("should have received %d requests, got %d", tt.wantRequests, len(bodies))
+			}
+			if errorCount != len(tt.statuses) {
+				t.Errorf("OnError should have been called %d times, got %d", len(tt.statuses), errorCount)
+			}
+			if contentType := headers[0].Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Content-Type should be application/json, got %s", contentType)
+			}
+			if headers[0].Get(SignatureHeader) != "" {
+				t.Error("requests should not be signed without a secret")
+			}
+
+			// a batch is an array, otherwise each request is a single event
+			lastBody := bodies[len(bodies)-1]
+			var events []folderWatcher.FileEvent
+			if tt.batchSize > 1 {
+				if err := json.Unmarshal(lastBody, &events); err != nil {
+					t.Fatal(err.Error())
+				}
+			} else {
+				var event folderWatcher.FileEvent
+				if err := json.Unmarshal(lastBody, &event); err != nil {
+					t.Fatal(err.Error())
+				}
+				events = append(events, event)
+			}
+			if len(events) != tt.wantLastCount || events[len(events)-1].Sequence != uint64(tt.eventCount) {
+				t.Errorf("the last request should have %d events ending with event %d, got %v", tt.wantLastCount, tt.eventCount, events)
+			}
+		})
+	}
+}
+
+func TestSink_Signature(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	secret := []byte("secret")
+	sink, _ := New(Config{URL: server.URL, Secret: secret})
+	runSink(t, sink, testEvents(1))
+
+	bodies, headers := r.received()
+	if len(bodies) != 1 {
+		t.Fatalf("should have received 1 request, got %d", len(bodies))
+	}
+	if signature := headers[0].Get(SignatureHeader); signature != Signature(secret, bodies[0]) {
+		t.Errorf("%s should be %s, got %s", SignatureHeader, Signature(secret, bodies[0]), signature)
+	}
+	if Signature([]byte("other"), bodies[0]) == Signature(secret, bodies[0]) {
+		t.Error("the signature should depend on the secret")
+	}
+}
+
+// Events which could not be delivered are sent by the next sink using the same folder
+func TestSink_QueueFolder(t *testing.T) {
+	queueFolder := t.TempDir()
+	unavailable := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	sink, _ := New(Config{URL: unavailable.URL, QueueFolder: queueFolder, MaxRetries: -1})
+	runSink(t, sink, testEvents(2))
+	if sink.Pending() != 2 {
+		t.Fatalf("2 batches should be waiting, got %d", sink.Pending())
+	}
+	if entries, _ := os.ReadDir(queueFolder); len(entries) != 2 {
+		t.Fatalf("2 batch files should be in the queue folder, got %d", len(entries))
+	}
+
+	// start again with a working server
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	sink, err := New(Config{URL: server.URL, QueueFolder: queueFolder})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	runSink(t, sink, testEvents(0))
+
+	bodies, _ := r.received()
+	if len(bodies) != 2 {
+		t.Fatalf("the 2 queued events should have been sent, got %d requests", len(bodies))
+	}
+	var first folderWatcher.FileEvent
+	if err = json.Unmarshal(bodies[0], &first); err != nil || first.Sequence != 1 {
+		t.Errorf("the queued events should be sent in order, got %s first", bodies[0])
+	}
+	if entries, _ := os.ReadDir(queueFolder); len(entries) != 0 || sink.Pending() != 0 {
+		t.Errorf("the queue should be empty after delivery, got %d files", len(entries))
+	}
+}
+
+// Events waiting for a batch to fill are queued when the context is cancelled
+func TestSink_RunCancelled(t *testing.T) {
+	sink, _ := New(Config{URL: "http://localhost", BatchSize: 10, BatchDelay: time.Hour})
+	channel := make(chan folderWatcher.FileEvent, 1)
+	channel <- testEvents(1)[0]
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- sink.Run(ctx, channel) }()
+
+	// wait for the event to be read before cancelling
+	for len(channel) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() should return context.Canceled, got %v", err)
+	}
+	if sink.Pending() != 1 {
+		t.Errorf("the event should be queued, got %d batches", sink.Pending())
+	}
+}